@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const DefHandlerQueueSize = 64
@@ -18,45 +21,104 @@ var (
 	ErrQueueFull      = errors.New("bus queue is full")
 )
 
+// OverflowPolicy decides which message is dropped when the buffer of a
+// paused subscription is full.
+type OverflowPolicy int
+
+const (
+	// DropOldest drops the oldest buffered message of the lowest priority.
+	DropOldest OverflowPolicy = iota
+	// DropNewest drops the incoming message.
+	DropNewest
+)
+
 // MessageBus implements publish/subscribe messaging paradigm
 type MessageBus interface {
 	// Publish publishes arguments to the given topic subscribers
 	// Publish block only when the buffer of one of the subscribers is full.
 	Publish(topic string, args ...interface{}) error
+	// PublishPriority publishes arguments with the given priority
+	PublishPriority(topic string, priority Priority, args ...interface{}) error
 	// Close unsubscribe all handlers from given topic
 	Close(topic string) error
 	// Subscribe subscribes to the given topic
 	Subscribe(topic string, fn interface{}) error
+	// SubscribeOnce subscribes to the given topic for a single message
+	SubscribeOnce(topic string, fn interface{}) error
+	// SubscribeWith subscribes to the given topic until the subscription expires
+	SubscribeWith(topic string, fn interface{}, opts ...SubscribeOption) error
 	// Unsubscribe unsubscribe handler from the given topic
 	Unsubscribe(topic string, fn interface{}) error
+	// Pause stops delivery to the handler and buffers up to limit messages
+	Pause(topic string, fn interface{}, limit int, policy OverflowPolicy) error
+	// Resume delivers the buffered messages in order and resumes delivery
+	Resume(topic string, fn interface{}) error
+	// Stats returns a snapshot of topics and their subscribers
+	Stats() BusStats
 }
 
 type handlersMap map[string][]*msgHandler
 
+type message struct {
+	args     []reflect.Value
+	priority Priority
+}
+
 type msgHandler struct {
 	callback reflect.Value
-	queue    chan []reflect.Value
+	queue    lanes[message]
+	slots    chan struct{} // Bounds the messages queued in all lanes together.
+	topic    string
+	state    *busState
+
+	// mtx guards the pause settings, wake nudges the handler goroutine
+	// when they change.
+	mtx     sync.Mutex
+	paused  bool
+	limit   int
+	policy  OverflowPolicy
+	wake    chan struct{}
+	pending atomic.Int64
+	dropped atomic.Uint64
+
+	// maxDeliveries and delivered are used by the handler goroutine only,
+	// expire removes the handler from the bus, timer enforces its TTL.
+	maxDeliveries int
+	delivered     int
+	expire        func()
+	timer         *time.Timer
 }
 
 type messageBus struct {
 	handlerQueueSize int
 	mtx              sync.RWMutex
 	handlers         handlersMap
+	state            *busState
 }
 
 // Publish publishes a message to the given topic in the message bus.
 //
 // It takes a topic string and a variable number of arguments as its parameters.
 // The function returns an error.
-func (b *messageBus) Publish(topic string, args ...interface{}) (err error) {
-	rArgs := buildHandlerArgs(args)
+func (b *messageBus) Publish(topic string, args ...interface{}) error {
+	return b.PublishPriority(topic, PriorityNormal, args...)
+}
+
+// PublishPriority publishes a message with the given priority. Every handler
+// processes queued messages of higher priority first, messages of the same
+// priority in publishing order. It blocks when the handler queue is full,
+// whatever the priorities of the queued messages.
+func (b *messageBus) PublishPriority(topic string, priority Priority, args ...interface{}) (err error) {
+	msg := message{args: buildHandlerArgs(args), priority: priority}
 
 	b.mtx.RLock()
 	defer b.mtx.RUnlock()
 
 	if hs, ok := b.handlers[topic]; ok {
+		b.state.retain(topic, args)
 		for _, h := range hs {
-			h.queue <- rArgs
+			h.slots <- struct{}{}
+			h.queue[priority.lane()] <- msg
 		}
 	} else {
 		err = ErrNoHandlerFound
@@ -73,29 +135,72 @@ func (b *messageBus) Publish(topic string, args ...interface{}) (err error) {
 // Returns:
 // - error: if there is an error validating the callback function.
 func (b *messageBus) Subscribe(topic string, fn interface{}) error {
+	return b.SubscribeWith(topic, fn)
+}
+
+// SubscribeOnce subscribes the handler for a single message. The handler is
+// unsubscribed automatically after it has been called.
+func (b *messageBus) SubscribeOnce(topic string, fn interface{}) error {
+	return b.SubscribeWith(topic, fn, WithMaxDeliveries(1))
+}
+
+// SubscribeWith subscribes the handler until one of the options expires it:
+// after a number of calls, after a TTL or at a deadline. An expired handler
+// is unsubscribed automatically and its queue is closed. Without options it
+// is the same as Subscribe.
+func (b *messageBus) SubscribeWith(topic string, fn interface{}, opts ...SubscribeOption) error {
 	if err := isValidHandler(fn); err != nil {
 		return err
 	}
 
+	cfg := newSubscribeConfig(opts)
+
 	h := &msgHandler{
 		callback: reflect.ValueOf(fn),
-		queue:    make(chan []reflect.Value, b.handlerQueueSize),
+		queue:    newLanes[message](b.handlerQueueSize),
+		slots:    make(chan struct{}, b.handlerQueueSize),
+		topic:    topic,
+		state:    b.state,
+		wake:     make(chan struct{}, 1),
+
+		maxDeliveries: cfg.maxDeliveries,
 	}
+	h.expire = func() { b.remove(topic, h) }
 
-	go func() {
-		for args := range h.queue {
-			h.callback.Call(args)
-		}
-	}()
+	go h.run()
 
 	b.mtx.Lock()
 	defer b.mtx.Unlock()
 
 	b.handlers[topic] = append(b.handlers[topic], h)
+	if cfg.limited() {
+		h.timer = cfg.expireAfter(h.expire)
+	}
 
 	return nil
 }
 
+// remove unsubscribes the given handler if it is still subscribed.
+func (b *messageBus) remove(topic string, h *msgHandler) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	hs := b.handlers[topic]
+	for i, x := range hs {
+		if x != h {
+			continue
+		}
+		h.close()
+		if len(hs) == 1 {
+			delete(b.handlers, topic)
+			b.state.forget(topic)
+		} else {
+			b.handlers[topic] = append(hs[:i], hs[i+1:]...)
+		}
+		return
+	}
+}
+
 // Unsubscribe unsubscribes a handler function from a specific topic in the message bus.
 //
 // It takes in the topic string and the handler function fn as parameters.
@@ -114,10 +219,11 @@ func (b *messageBus) Unsubscribe(topic string, fn interface{}) error {
 	if _, ok := b.handlers[topic]; ok {
 		for i, h := range b.handlers[topic] {
 			if h.callback == rv {
-				close(h.queue)
+				h.close()
 
 				if len(b.handlers[topic]) == 1 {
 					delete(b.handlers, topic)
+					b.state.forget(topic)
 				} else {
 					b.handlers[topic] = append(b.handlers[topic][:i], b.handlers[topic][i+1:]...)
 				}
@@ -140,10 +246,11 @@ func (b *messageBus) Close(topic string) (err error) {
 
 	if _, ok := b.handlers[topic]; ok {
 		for _, h := range b.handlers[topic] {
-			close(h.queue)
+			h.close()
 		}
 
 		delete(b.handlers, topic)
+		b.state.forget(topic)
 	} else {
 		err = ErrTopicNotFound
 	}
@@ -151,6 +258,236 @@ func (b *messageBus) Close(topic string) (err error) {
 	return err
 }
 
+// Stats returns a snapshot of the message bus topics, their handlers and
+// queue usage together with the last arguments published to every topic
+// when the bus retains them.
+func (b *messageBus) Stats() BusStats {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	subs := make(map[string][]SubscriberStats, len(b.handlers))
+	for topic, hs := range b.handlers {
+		ss := make([]SubscriberStats, 0, len(hs))
+		for _, h := range hs {
+			ss = append(ss, SubscriberStats{
+				Handler:  handlerName(h.callback),
+				QueueLen: len(h.slots),
+				QueueCap: cap(h.slots),
+				Dropped:  h.dropped.Load(),
+				Paused:   h.isPaused(),
+				Pending:  int(h.pending.Load()),
+			})
+		}
+		subs[topic] = ss
+	}
+
+	return b.state.snapshot(subs)
+}
+
+// Pause stops calling the handler subscribed to the topic without
+// unsubscribing it. Publishing does not block on a paused handler: its
+// messages are buffered, up to limit of them, and the policy decides which
+// ones are dropped beyond that. A limit below 1 uses the handler queue
+// size. Pausing a paused handler updates its limit and policy.
+func (b *messageBus) Pause(topic string, fn interface{}, limit int, policy OverflowPolicy) error {
+	h, err := b.findHandler(topic, fn)
+	if err != nil {
+		return err
+	}
+
+	if limit < 1 {
+		limit = b.handlerQueueSize
+	}
+
+	h.mtx.Lock()
+	h.paused = true
+	h.limit = limit
+	h.policy = policy
+	h.mtx.Unlock()
+
+	h.notify()
+	return nil
+}
+
+// Resume delivers the messages buffered while the handler was paused,
+// by priority and in order, before any message published later.
+func (b *messageBus) Resume(topic string, fn interface{}) error {
+	h, err := b.findHandler(topic, fn)
+	if err != nil {
+		return err
+	}
+
+	h.mtx.Lock()
+	h.paused = false
+	h.mtx.Unlock()
+
+	h.notify()
+	return nil
+}
+
+// findHandler returns the first handler fn subscribed to the topic.
+func (b *messageBus) findHandler(topic string, fn interface{}) (*msgHandler, error) {
+	if err := isValidHandler(fn); err != nil {
+		return nil, err
+	}
+
+	rv := reflect.ValueOf(fn)
+
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	hs, ok := b.handlers[topic]
+	if !ok {
+		return nil, ErrTopicNotFound
+	}
+	for _, h := range hs {
+		if h.callback == rv {
+			return h, nil
+		}
+	}
+	return nil, ErrNoHandlerFound
+}
+
+// run calls the handler for every queued message until the queue is closed.
+// While paused the queue is still drained into a local buffer, so that
+// publishers do not block, and the buffer is replayed on resume.
+func (h *msgHandler) run() {
+	var pending pendingQueue
+
+	// The handler keeps its own copy of the lanes to forget closed ones.
+	queue := h.queue
+
+	for {
+		if pending.len() > 0 && !h.isPaused() {
+			args := pending.pop()
+			h.pending.Store(int64(pending.len()))
+			h.deliver(args)
+			continue
+		}
+
+		msg, res := receiveByPriority(&queue, h.wake)
+		switch res {
+		case laneClosed:
+			h.pending.Store(0)
+			return
+		case laneSignal:
+			continue
+		}
+		<-h.slots
+
+		// Pause may have been called while waiting.
+		if !h.isPaused() {
+			h.deliver(msg.args)
+			continue
+		}
+		h.buffer(&pending, msg)
+		h.pending.Store(int64(pending.len()))
+	}
+}
+
+// buffer adds the message to the pending ones applying the overflow policy.
+// DropOldest evicts the oldest message of the lowest priority, which is the
+// incoming one if its priority is below all the buffered ones.
+func (h *msgHandler) buffer(pending *pendingQueue, msg message) {
+	h.mtx.Lock()
+	limit, policy := h.limit, h.policy
+	h.mtx.Unlock()
+
+	for pending.len() >= limit {
+		h.dropped.Add(1)
+		h.state.drop(h.topic, 0)
+		if policy == DropNewest || msg.priority.lane() < pending.lowest() {
+			return
+		}
+		pending.take(pending.lowest())
+	}
+	pending.push(msg)
+}
+
+// pendingQueue holds the messages buffered while a handler is paused,
+// one FIFO per priority.
+type pendingQueue struct {
+	lanes [priorityLevels][][]reflect.Value
+	n     int
+}
+
+func (q *pendingQueue) len() int {
+	return q.n
+}
+
+func (q *pendingQueue) push(msg message) {
+	i := msg.priority.lane()
+	q.lanes[i] = append(q.lanes[i], msg.args)
+	q.n++
+}
+
+// pop removes the oldest message of the highest priority.
+func (q *pendingQueue) pop() []reflect.Value {
+	for i := priorityLevels - 1; i >= 0; i-- {
+		if len(q.lanes[i]) > 0 {
+			return q.take(i)
+		}
+	}
+	return nil
+}
+
+// lowest returns the lowest non-empty lane, priorityLevels if none.
+func (q *pendingQueue) lowest() int {
+	for i := 0; i < priorityLevels; i++ {
+		if len(q.lanes[i]) > 0 {
+			return i
+		}
+	}
+	return priorityLevels
+}
+
+func (q *pendingQueue) take(i int) []reflect.Value {
+	args := q.lanes[i][0]
+	q.lanes[i][0] = nil
+	q.lanes[i] = q.lanes[i][1:]
+	q.n--
+	return args
+}
+
+// deliver calls the handler unless it has used up its deliveries. The
+// goroutine keeps draining the queue until the handler is removed, so that
+// publishers never block on an expired handler.
+func (h *msgHandler) deliver(args []reflect.Value) {
+	if h.maxDeliveries > 0 && h.delivered >= h.maxDeliveries {
+		return
+	}
+
+	h.callback.Call(args)
+	h.delivered++
+
+	if h.delivered == h.maxDeliveries {
+		go h.expire()
+	}
+}
+
+// close stops the handler goroutine. It must be called with the bus lock
+// held while the handler is being removed from the bus.
+func (h *msgHandler) close() {
+	h.queue.close()
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+}
+
+func (h *msgHandler) isPaused() bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.paused
+}
+
+// notify wakes up the handler goroutine.
+func (h *msgHandler) notify() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
 // isValidHandler checks if the given function is a valid handler.
 //
 // fn: the function to be checked.
@@ -164,6 +501,14 @@ func isValidHandler(fn interface{}) (err error) {
 	return err
 }
 
+// handlerName returns the name of the function behind the handler.
+func handlerName(fn reflect.Value) string {
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return f.Name()
+	}
+	return fn.Type().String()
+}
+
 // buildHandlerArgs creates an array of reflect.Value objects from an array of interface{} objects.
 //
 // args: The array of interface{} objects to be converted.
@@ -180,7 +525,7 @@ func buildHandlerArgs(args []interface{}) []reflect.Value {
 
 // NewMessageBus creates new MessageBus
 // handlerQueueSize sets buffered channel length per subscriber
-func NewMessageBus(handlerQueueSize int) MessageBus {
+func NewMessageBus(handlerQueueSize int, opts ...BusOption) MessageBus {
 	if handlerQueueSize < 1 {
 		handlerQueueSize = DefHandlerQueueSize
 	}
@@ -188,5 +533,6 @@ func NewMessageBus(handlerQueueSize int) MessageBus {
 	return &messageBus{
 		handlerQueueSize: handlerQueueSize,
 		handlers:         make(handlersMap),
+		state:            newBusState(opts),
 	}
 }
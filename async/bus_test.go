@@ -5,6 +5,7 @@ import (
 	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -177,3 +178,109 @@ func TestHandleError(t *testing.T) {
 
 	assert.Error(t, <-out, "Expected an error from the handler")
 }
+
+func TestPauseResume(t *testing.T) {
+	bus := NewMessageBus(4)
+
+	var (
+		mtx sync.Mutex
+		got []int
+	)
+	handler := func(v int) {
+		mtx.Lock()
+		got = append(got, v)
+		mtx.Unlock()
+	}
+	received := func() []int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]int(nil), got...)
+	}
+
+	err := bus.Subscribe("topic", handler)
+	assert.NoError(t, err, "Expected no error when subscribing a valid handler")
+
+	err = bus.Pause("topic", handler, 10, DropOldest)
+	assert.NoError(t, err, "Expected no error when pausing a subscribed handler")
+
+	// More messages than the handler queue holds must not block while paused.
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, bus.Publish("topic", i))
+	}
+
+	assert.Eventually(t, func() bool {
+		s := bus.Stats().Topics[0].Subscribers[0]
+		return s.Paused && s.Pending == 10 && s.QueueLen == 0
+	}, time.Second, time.Millisecond, "Expected messages to be buffered while paused")
+	assert.Empty(t, received(), "Expected no delivery while paused")
+
+	err = bus.Resume("topic", handler)
+	assert.NoError(t, err, "Expected no error when resuming a paused handler")
+	assert.NoError(t, bus.Publish("topic", -1))
+
+	var want []int
+	for i := 10; i < 20; i++ {
+		want = append(want, i)
+	}
+	want = append(want, -1)
+
+	assert.Eventually(t, func() bool {
+		return len(received()) == len(want)
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, want, received(), "Expected buffered messages first and in order")
+
+	s := bus.Stats().Topics[0].Subscribers[0]
+	assert.False(t, s.Paused)
+	assert.Equal(t, uint64(10), s.Dropped)
+}
+
+func TestPause_DropNewest(t *testing.T) {
+	bus := NewMessageBus(runtime.NumCPU())
+
+	got := make(chan int, 10)
+	handler := func(v int) { got <- v }
+
+	assert.NoError(t, bus.Subscribe("topic", handler))
+	assert.NoError(t, bus.Pause("topic", handler, 2, DropNewest))
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish("topic", i))
+	}
+	assert.Eventually(t, func() bool {
+		return bus.Stats().Topics[0].Subscribers[0].Dropped == 3
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, bus.Resume("topic", handler))
+	assert.Equal(t, 0, <-got)
+	assert.Equal(t, 1, <-got)
+
+	drops := bus.Stats().RecentDrops
+	assert.Len(t, drops, 3)
+	assert.Equal(t, "topic", drops[0].Topic)
+}
+
+func TestPause_NotFound(t *testing.T) {
+	bus := NewMessageBus(runtime.NumCPU())
+
+	handler := func() {}
+	assert.NoError(t, bus.Subscribe("topic", handler))
+
+	err := bus.Pause("no-topic", handler, 1, DropOldest)
+	assert.ErrorIs(t, err, ErrTopicNotFound)
+
+	err = bus.Pause("topic", func() {}, 1, DropOldest)
+	assert.ErrorIs(t, err, ErrNoHandlerFound)
+
+	err = bus.Resume("topic", 2)
+	assert.Error(t, err, "Expected an error when resuming with an invalid handler")
+}
+
+func TestPause_Unsubscribe(t *testing.T) {
+	bus := NewMessageBus(runtime.NumCPU())
+
+	handler := func(int) { t.Error("Unexpected delivery to an unsubscribed handler") }
+	assert.NoError(t, bus.Subscribe("topic", handler))
+	assert.NoError(t, bus.Pause("topic", handler, 0, DropOldest))
+	assert.NoError(t, bus.Publish("topic", 1))
+	assert.NoError(t, bus.Unsubscribe("topic", handler))
+}
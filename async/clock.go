@@ -0,0 +1,124 @@
+package async
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so that time driven components can be tested
+// without sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer returns a Timer which sends the current time on its channel
+	// once the duration has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer of a Clock.
+type Timer interface {
+	// C returns the channel on which the time is sent when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer
+	// has already fired or been stopped.
+	Stop() bool
+}
+
+// RealClock is the Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                 { return time.Now() }
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// FakeClock is a Clock which only moves when told to. It is safe for
+// concurrent use.
+type FakeClock struct {
+	mtx     sync.Mutex
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+// NewFakeClock creates a FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer which fires once the clock has been advanced by
+// at least d. It is pending until then, or until it is stopped.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.ch <- c.now
+		return t
+	}
+	c.waiters = append(c.waiters, t)
+	return t
+}
+
+// Advance moves the clock forward and fires every expired timer.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, t := range c.waiters {
+		if t.at.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	clear(c.waiters[len(pending):])
+	c.waiters = pending
+}
+
+// Waiters returns the number of pending timers. Tests use it to make sure
+// a component is blocked on the clock before advancing it.
+func (c *FakeClock) Waiters() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.waiters)
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for i, w := range c.waiters {
+		if w == t {
+			c.waiters = slices.Delete(c.waiters, i, i+1)
+			return true
+		}
+	}
+	return false
+}
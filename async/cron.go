@@ -0,0 +1,360 @@
+package async
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid schedule spec")
+
+// Schedule describes a job's duty cycle.
+type Schedule interface {
+	// Next returns the next activation time, later than the given time.
+	// A zero time means the schedule will never fire again.
+	Next(time.Time) time.Time
+}
+
+// ParseSchedule parses a schedule spec. It accepts
+//   - standard 5 field cron expressions: minute hour day-of-month month day-of-week;
+//   - 6 field cron expressions with a leading seconds field;
+//   - the descriptors @yearly, @annually, @monthly, @weekly, @daily,
+//     @midnight and @hourly;
+//   - fixed intervals in the form "@every <duration>", e.g. "@every 1m30s".
+//
+// Fields accept *, ?, single values, ranges (a-b), lists (a,b) and steps
+// (*/n, a-b/n, a/n). Months and week days accept three letter English
+// names, Sunday is both 0 and 7. When both day fields are restricted a day
+// matches if either of them does. On daylight saving changes, a time which
+// does not exist that day is skipped and a repeated time fires once.
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@") {
+		return parseDescriptor(spec)
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: expected 5 or 6 fields, found %d: %q", ErrInvalidSpec, len(fields), spec)
+	}
+
+	var (
+		s   cronSchedule
+		err error
+	)
+
+	if s.second, err = parseField(fields[0], secondBounds); err != nil {
+		return nil, err
+	}
+	if s.minute, err = parseField(fields[1], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[2], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[3], domBounds); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[4], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[5], dowBounds); err != nil {
+		return nil, err
+	}
+
+	// Sunday may be written as 7.
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+
+	return &s, nil
+}
+
+// Every returns a Schedule which fires every d. Durations below a
+// nanosecond are rounded up to a nanosecond.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+// Next returns t plus the interval.
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronSchedule keeps every field as a bit set of the allowed values.
+// starBit marks fields given as * or ?, which matters for the day fields.
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+}
+
+const starBit = 1 << 63
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondBounds = bounds{min: 0, max: 59}
+	minuteBounds = bounds{min: 0, max: 59}
+	hourBounds   = bounds{min: 0, max: 23}
+	domBounds    = bounds{min: 1, max: 31}
+	monthBounds  = bounds{min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// parseDescriptor handles the @ prefixed specs.
+func parseDescriptor(spec string) (Schedule, error) {
+	all := func(b bounds) uint64 { return bitRange(b.min, b.max, 1) | starBit }
+
+	switch strings.ToLower(spec) {
+	case "@yearly", "@annually":
+		return &cronSchedule{second: 1, minute: 1, hour: 1, dom: 1 << 1, month: 1 << 1, dow: all(dowBounds)}, nil
+	case "@monthly":
+		return &cronSchedule{second: 1, minute: 1, hour: 1, dom: 1 << 1, month: all(monthBounds), dow: all(dowBounds)}, nil
+	case "@weekly":
+		return &cronSchedule{second: 1, minute: 1, hour: 1, dom: all(domBounds), month: all(monthBounds), dow: 1}, nil
+	case "@daily", "@midnight":
+		return &cronSchedule{second: 1, minute: 1, hour: 1, dom: all(domBounds), month: all(monthBounds), dow: all(dowBounds)}, nil
+	case "@hourly":
+		return &cronSchedule{second: 1, minute: 1, hour: all(hourBounds), dom: all(domBounds), month: all(monthBounds), dow: all(dowBounds)}, nil
+	}
+
+	const every = "@every "
+	if strings.HasPrefix(strings.ToLower(spec), every) {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len(every):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: bad interval: %q", ErrInvalidSpec, spec)
+		}
+		return Every(d), nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown descriptor: %q", ErrInvalidSpec, spec)
+}
+
+// parseField parses a comma separated list of ranges into a bit set.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		r, err := parseRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= r
+	}
+	return bits, nil
+}
+
+// parseRange parses a single expression: *, ?, n, a-b with an optional /step.
+func parseRange(expr string, b bounds) (uint64, error) {
+	var (
+		lo, hi uint
+		step   uint = 1
+		extra  uint64
+	)
+
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, fmt.Errorf("%w: too many slashes: %q", ErrInvalidSpec, expr)
+	}
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	if len(lowAndHigh) > 2 {
+		return 0, fmt.Errorf("%w: too many hyphens: %q", ErrInvalidSpec, expr)
+	}
+
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) > 1 {
+			return 0, fmt.Errorf("%w: wildcard in range: %q", ErrInvalidSpec, expr)
+		}
+		lo, hi = b.min, b.max
+		extra = starBit
+	} else {
+		var err error
+		if lo, err = parseValue(lowAndHigh[0], b); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if len(lowAndHigh) == 2 {
+			if hi, err = parseValue(lowAndHigh[1], b); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(rangeAndStep) == 2 {
+		n, err := strconv.ParseUint(rangeAndStep[1], 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("%w: bad step: %q", ErrInvalidSpec, expr)
+		}
+		step = uint(n)
+		// "n/step" means from n to the maximum.
+		if len(lowAndHigh) == 1 && extra == 0 {
+			hi = b.max
+		}
+		// A stepped wildcard is a restriction, not a wildcard.
+		extra = 0
+	}
+
+	if lo > hi {
+		return 0, fmt.Errorf("%w: range start is beyond its end: %q", ErrInvalidSpec, expr)
+	}
+
+	return bitRange(lo, hi, step) | extra, nil
+}
+
+// parseValue parses a number or a name within the bounds.
+func parseValue(s string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad value: %q", ErrInvalidSpec, s)
+	}
+	v := uint(n)
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%w: %d is out of range [%d, %d]", ErrInvalidSpec, v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// bitRange sets every step-th bit from lo to hi inclusive.
+func bitRange(lo, hi, step uint) uint64 {
+	var bits uint64
+	for i := lo; i <= hi; i += step {
+		bits |= 1 << i
+	}
+	return bits
+}
+
+// Next returns the first time matching the schedule after t. The search
+// is done in t's location and gives up after five years. A time which does
+// not exist on the day the clocks go forward is skipped that day, and the
+// times repeated when they go back fire only once.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	prev := wallClock(t)
+	for {
+		t = s.next(t)
+		// The wall-clock time already fired before the clocks went back.
+		if t.IsZero() || wallClock(t).After(prev) {
+			return t
+		}
+	}
+}
+
+// next returns the first time matching the schedule after t.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+
+	// Start at the next whole second.
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+
+	// truncated reports whether the lower units have already been reset,
+	// which has to happen only once when a field does not match.
+	truncated := false
+
+wrap:
+	for t.Year() <= yearLimit {
+		for 1<<uint(t.Month())&s.month == 0 {
+			if !truncated {
+				truncated = true
+				t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+			}
+			t = t.AddDate(0, 1, 0)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+
+		for !s.dayMatches(t) {
+			if !truncated {
+				truncated = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			}
+			t = t.AddDate(0, 0, 1)
+			// Midnight may not exist on DST transition days.
+			if t.Hour() != 0 {
+				if t.Hour() > 12 {
+					t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+				} else {
+					t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+				}
+			}
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+
+		for 1<<uint(t.Hour())&s.hour == 0 {
+			if !truncated {
+				truncated = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+			}
+			t = t.Add(time.Hour)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+
+		for 1<<uint(t.Minute())&s.minute == 0 {
+			if !truncated {
+				truncated = true
+				t = t.Truncate(time.Minute)
+			}
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+
+		for 1<<uint(t.Second())&s.second == 0 {
+			if !truncated {
+				truncated = true
+				t = t.Truncate(time.Second)
+			}
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+
+		return t.In(loc)
+	}
+
+	return time.Time{}
+}
+
+// wallClock returns the date and time shown by a clock in t's location,
+// to the second.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
+
+// dayMatches applies the cron rule for the two day fields: if either of
+// them is a wildcard both must match, otherwise one of them is enough.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom != 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow != 0
+	if s.dom&starBit != 0 || s.dow&starBit != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,120 @@
+package async
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-03-22 10:15:30", "2024-03-22 10:16:00"},
+		{"*/15 * * * *", "2024-03-22 10:15:00", "2024-03-22 10:30:00"},
+		{"30 * * * * *", "2024-03-22 10:15:30", "2024-03-22 10:16:30"},
+		{"0 9-17/4 * * *", "2024-03-22 10:00:00", "2024-03-22 13:00:00"},
+		{"0 0 1 * *", "2024-03-22 10:00:00", "2024-04-01 00:00:00"},
+		{"0 0 29 feb *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		{"0 12 * * mon-fri", "2024-03-22 12:00:00", "2024-03-25 12:00:00"},
+		{"0 0 * * 7", "2024-03-22 00:00:00", "2024-03-24 00:00:00"},
+		// Both day fields restricted: either the 1st or a Monday.
+		{"0 0 1 * 1", "2024-03-22 00:00:00", "2024-03-25 00:00:00"},
+		{"0 0 1 * 1", "2024-03-25 00:00:00", "2024-04-01 00:00:00"},
+		{"5/20 0 * * *", "2024-03-22 00:00:00", "2024-03-22 00:05:00"},
+		{"@hourly", "2024-03-22 10:15:00", "2024-03-22 11:00:00"},
+		{"@daily", "2024-03-22 10:15:00", "2024-03-23 00:00:00"},
+		{"@weekly", "2024-03-22 10:15:00", "2024-03-24 00:00:00"},
+		{"@monthly", "2024-03-22 10:15:00", "2024-04-01 00:00:00"},
+		{"@yearly", "2024-03-22 10:15:00", "2025-01-01 00:00:00"},
+		{"@every 90s", "2024-03-22 10:15:00", "2024-03-22 10:16:30"},
+	}
+
+	const layout = "2006-01-02 15:04:05"
+	for _, tt := range tests {
+		sched, err := ParseSchedule(tt.spec)
+		if !assert.NoError(t, err, "Unexpected error parsing %q", tt.spec) {
+			continue
+		}
+
+		from, _ := time.ParseInLocation(layout, tt.from, time.UTC)
+		got := sched.Next(from)
+
+		assert.Equal(t, tt.want, got.Format(layout), "Unexpected next time for %q from %s", tt.spec, tt.from)
+	}
+}
+
+func TestParseSchedule_Location(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	sched, err := ParseSchedule("0 9 * * *")
+	assert.NoError(t, err)
+
+	from := time.Date(2024, 3, 22, 10, 0, 0, 0, loc)
+	got := sched.Next(from)
+
+	assert.Equal(t, time.Date(2024, 3, 23, 9, 0, 0, 0, loc), got)
+	assert.Equal(t, loc, got.Location())
+}
+
+func TestParseSchedule_DST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	// The clocks go back from 02:00 EDT to 01:00 EST on 2024-11-03.
+	sched, err := ParseSchedule("0 30 1 * * *")
+	assert.NoError(t, err)
+	first := time.Date(2024, 11, 3, 1, 30, 0, 0, loc)
+	assert.Equal(t, "EDT", first.Format("MST"))
+	assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, loc), sched.Next(first), "Expected the repeated time to fire once")
+	assert.Equal(t, time.Date(2024, 11, 4, 1, 30, 0, 0, loc), sched.Next(first.Add(time.Second)))
+
+	sched, err = ParseSchedule("0 * * * *")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Add(90*time.Minute), sched.Next(first), "Expected the hours after the change to fire")
+
+	// 02:30 does not exist on 2024-03-10, when the clocks go forward.
+	sched, err = ParseSchedule("30 2 * * *")
+	assert.NoError(t, err)
+	from := time.Date(2024, 3, 9, 12, 0, 0, 0, loc)
+	assert.Equal(t, time.Date(2024, 3, 11, 2, 30, 0, 0, loc), sched.Next(from), "Expected the missing time to be skipped")
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * foo *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"1-2-3 * * * *",
+		"*-5 * * * *",
+		"@often",
+		"@every never",
+	}
+
+	for _, spec := range specs {
+		_, err := ParseSchedule(spec)
+		assert.ErrorIs(t, err, ErrInvalidSpec, "Expected an error parsing %q", spec)
+	}
+}
+
+func TestParseSchedule_Never(t *testing.T) {
+	sched, err := ParseSchedule("0 0 31 feb *")
+	assert.NoError(t, err)
+
+	assert.True(t, sched.Next(time.Now()).IsZero(), "Expected a schedule which never fires")
+}
@@ -0,0 +1,143 @@
+package async
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatsProvider is implemented by both MessageBus and EventBus.
+type StatsProvider interface {
+	Stats() BusStats
+}
+
+// debugView is the rendered form of BusStats. Retained values are
+// formatted as strings because they may hold values that cannot be
+// encoded as JSON, e.g. channels or functions.
+type debugView struct {
+	Name        string           `json:"name"`
+	Time        time.Time        `json:"time"`
+	Topics      []debugTopicView `json:"topics"`
+	RecentDrops []DropRecord     `json:"recent_drops"`
+}
+
+type debugTopicView struct {
+	Topic       string            `json:"topic"`
+	Subscribers []SubscriberStats `json:"subscribers"`
+	Retained    string            `json:"retained,omitempty"`
+}
+
+type debugHandler struct {
+	name string
+	bus  StatsProvider
+}
+
+// NewDebugHandler returns an http.Handler which renders the live state of
+// the bus: topics, subscribers with their queue usage, retained values and
+// recent drops. The response is JSON when the request has format=json in
+// the query or accepts application/json, and an HTML page otherwise.
+func NewDebugHandler(name string, bus StatsProvider) http.Handler {
+	return &debugHandler{name: name, bus: bus}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *debugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	view := h.view()
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		//#nosec G104 -- Nothing to do if the client went away.
+		enc.Encode(view)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := debugPage.Execute(w, view); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *debugHandler) view() debugView {
+	stats := h.bus.Stats()
+
+	view := debugView{
+		Name:        h.name,
+		Time:        time.Now(),
+		Topics:      make([]debugTopicView, 0, len(stats.Topics)),
+		RecentDrops: stats.RecentDrops,
+	}
+
+	for _, ts := range stats.Topics {
+		tv := debugTopicView{Topic: ts.Topic, Subscribers: ts.Subscribers}
+		if ts.Retained != nil {
+			tv.Retained = fmt.Sprintf("%+v", ts.Retained)
+		}
+		view.Topics = append(view.Topics, tv)
+	}
+
+	return view
+}
+
+// wantsJSON checks whether the client asked for a JSON response.
+func wantsJSON(r *http.Request) bool {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return strings.EqualFold(f, "json")
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+var debugPage = template.Must(template.New("bus").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>{{.Time.Format "2006-01-02 15:04:05.000 MST"}} &middot; {{len .Topics}} topic(s) &middot; <a href="?format=json">json</a></p>
+<h2>Topics</h2>
+<table>
+<tr><th>Topic</th><th>Subscriber</th><th>Queue</th><th>Dropped</th><th>Retained</th></tr>
+{{- range .Topics}}
+{{- $t := .}}
+{{- if .Subscribers}}
+{{- range $i, $s := .Subscribers}}
+<tr>
+<td>{{if eq $i 0}}{{$t.Topic}}{{end}}</td>
+<td>{{if $s.Handler}}{{$s.Handler}}{{else}}{{$s.ID}}{{end}}</td>
+<td>{{$s.QueueLen}}/{{$s.QueueCap}}{{if $s.Paused}} paused, {{$s.Pending}} pending{{end}}</td>
+<td>{{$s.Dropped}}</td>
+<td>{{if eq $i 0}}{{$t.Retained}}{{end}}</td>
+</tr>
+{{- end}}
+{{- else}}
+<tr><td>{{.Topic}}</td><td>-</td><td>-</td><td>-</td><td>{{.Retained}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+<h2>Recent drops</h2>
+<table>
+<tr><th>Time</th><th>Topic</th><th>Subscription</th></tr>
+{{- range .RecentDrops}}
+<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Topic}}</td><td>{{.SubscriptionID}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,142 @@
+package async
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBus_Stats(t *testing.T) {
+	bus := NewMessageBus(runtime.NumCPU(), WithRetention())
+
+	block := make(chan struct{})
+	defer close(block)
+
+	err := bus.Subscribe("topic", func(v int) { <-block })
+	assert.NoError(t, err, "Expected no error when subscribing a valid handler")
+
+	assert.NoError(t, bus.Publish("topic", 1))
+	assert.NoError(t, bus.Publish("topic", 2))
+
+	stats := bus.Stats()
+	assert.Len(t, stats.Topics, 1)
+	assert.Equal(t, "topic", stats.Topics[0].Topic)
+	assert.Equal(t, []interface{}{2}, stats.Topics[0].Retained, "Expected last published arguments")
+	assert.Len(t, stats.Topics[0].Subscribers, 1)
+
+	sub := stats.Topics[0].Subscribers[0]
+	assert.Contains(t, sub.Handler, "TestMessageBus_Stats")
+	assert.Equal(t, runtime.NumCPU(), sub.QueueCap, "Expected the lanes to share the queue size")
+}
+
+func TestEventBus_Stats(t *testing.T) {
+	eb := NewEventBus(WithRetention())
+	ch := make(EventChannel, 1)
+	id := eb.Subscribe("topic", ch)
+
+	assert.NoError(t, eb.Publish("topic", "first"))
+	assert.Error(t, eb.Publish("topic", "second"), "Expected an error when the queue is full")
+
+	stats := eb.Stats()
+	assert.Len(t, stats.Topics, 1)
+	assert.Equal(t, "second", stats.Topics[0].Retained)
+
+	sub := stats.Topics[0].Subscribers[0]
+	assert.Equal(t, id, sub.ID)
+	assert.Equal(t, 1, sub.QueueLen)
+	assert.Equal(t, 1, sub.QueueCap)
+	assert.Equal(t, uint64(1), sub.Dropped)
+
+	assert.Len(t, stats.RecentDrops, 1)
+	assert.Equal(t, "topic", stats.RecentDrops[0].Topic)
+	assert.Equal(t, id, stats.RecentDrops[0].SubscriptionID)
+}
+
+func TestEventBus_Retention(t *testing.T) {
+	eb := NewEventBus()
+	eb.Subscribe("topic", make(EventChannel, 1))
+	assert.NoError(t, eb.Publish("topic", 1))
+	assert.Nil(t, eb.Stats().Topics[0].Retained, "Expected no retention by default")
+
+	eb = NewEventBus(WithRetention())
+	assert.ErrorIs(t, eb.Publish("other", 1), ErrNoHandlerFound)
+	assert.Empty(t, eb.Stats().Topics, "Expected topics without subscribers not to be retained")
+
+	a := eb.Subscribe("topic", make(EventChannel, 1))
+	b := eb.Subscribe("topic", make(EventChannel, 1))
+	assert.NoError(t, eb.Publish("topic", 1))
+	eb.Unsubscribe("topic", a)
+	assert.Equal(t, 1, eb.Stats().Topics[0].Retained)
+	eb.Unsubscribe("topic", b)
+	assert.Nil(t, eb.Stats().Topics[0].Retained, "Expected the value to be forgotten with the last subscriber")
+}
+
+func TestMessageBus_Retention(t *testing.T) {
+	bus := NewMessageBus(1, WithRetention())
+	fn := func(int) {}
+	assert.NoError(t, bus.Subscribe("topic", fn))
+	assert.NoError(t, bus.Publish("topic", 1))
+	assert.Equal(t, []interface{}{1}, bus.Stats().Topics[0].Retained)
+
+	assert.NoError(t, bus.Unsubscribe("topic", fn))
+	assert.Empty(t, bus.Stats().Topics, "Expected the value to be forgotten with the last handler")
+}
+
+func TestBusState_DropLog(t *testing.T) {
+	s := newBusState(nil)
+
+	for i := 0; i < dropLogSize+3; i++ {
+		s.drop("topic", uint64(i))
+	}
+
+	drops := s.snapshot(nil).RecentDrops
+	assert.Len(t, drops, dropLogSize)
+	assert.Equal(t, uint64(3), drops[0].SubscriptionID, "Expected oldest drops to be evicted")
+	assert.Equal(t, uint64(dropLogSize+2), drops[len(drops)-1].SubscriptionID)
+}
+
+func TestDebugHandler_JSON(t *testing.T) {
+	eb := NewEventBus(WithRetention())
+	eb.Subscribe("topic", make(EventChannel, 4))
+	assert.NoError(t, eb.Publish("topic", make(chan int)))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/debug/bus?format=json", nil)
+	NewDebugHandler("events", eb).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var view debugView
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &view))
+	assert.Equal(t, "events", view.Name)
+	assert.Len(t, view.Topics, 1)
+	assert.Equal(t, 4, view.Topics[0].Subscribers[0].QueueCap)
+	assert.NotEmpty(t, view.Topics[0].Retained, "Expected non JSON values to be rendered as text")
+}
+
+func TestDebugHandler_HTML(t *testing.T) {
+	bus := NewMessageBus(0)
+	assert.NoError(t, bus.Subscribe("<topic>", func() {}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/debug/bus", nil)
+	NewDebugHandler("messages", bus).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, rec.Body.String(), "&lt;topic&gt;", "Expected topic names to be escaped")
+}
+
+func TestDebugHandler_Method(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/debug/bus", nil)
+	NewDebugHandler("events", NewEventBus()).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
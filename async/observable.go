@@ -0,0 +1,136 @@
+package async
+
+import (
+	"context"
+	"sync"
+)
+
+// Change is a value of an Observable together with its version.
+type Change[T any] struct {
+	Value   T
+	Version uint64
+}
+
+// ObservableOption configures an Observable.
+type ObservableOption[T any] func(*Observable[T])
+
+// WithEqual sets the function used to detect no-op updates. When it reports
+// the new value equal to the current one, the version is not bumped and
+// watchers are not notified. Without it every Set is a change.
+func WithEqual[T any](equal func(a, b T) bool) ObservableOption[T] {
+	return func(o *Observable[T]) {
+		o.equal = equal
+	}
+}
+
+// Observable holds a value and notifies watchers when it changes.
+// It is safe for concurrent use.
+type Observable[T any] struct {
+	mtx      sync.RWMutex
+	value    T
+	version  uint64
+	equal    func(a, b T) bool
+	watchers map[chan Change[T]]struct{}
+}
+
+// NewObservable creates an Observable holding the initial value at version 0.
+func NewObservable[T any](initial T, opts ...ObservableOption[T]) *Observable[T] {
+	o := &Observable[T]{
+		value:    initial,
+		watchers: make(map[chan Change[T]]struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Get returns the current value.
+func (o *Observable[T]) Get() T {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return o.value
+}
+
+// Load returns the current value with its version.
+func (o *Observable[T]) Load() Change[T] {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return Change[T]{Value: o.value, Version: o.version}
+}
+
+// Version returns the current version. It grows by one with every change.
+func (o *Observable[T]) Version() uint64 {
+	o.mtx.RLock()
+	defer o.mtx.RUnlock()
+	return o.version
+}
+
+// Set stores the value and reports whether it was a change.
+func (o *Observable[T]) Set(v T) bool {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.store(v)
+}
+
+// Update atomically replaces the value with fn applied to it. It returns
+// the resulting value and whether it was a change.
+func (o *Observable[T]) Update(fn func(T) T) (T, bool) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	changed := o.store(fn(o.value))
+	return o.value, changed
+}
+
+// Watch returns a channel which receives the current value right away and
+// then every change. A watcher which falls behind sees only the latest
+// change; intermediate ones are coalesced, so versions may skip. The
+// channel is closed when ctx is done.
+func (o *Observable[T]) Watch(ctx context.Context) <-chan Change[T] {
+	ch := make(chan Change[T], 1)
+
+	o.mtx.Lock()
+	ch <- Change[T]{Value: o.value, Version: o.version}
+	o.watchers[ch] = struct{}{}
+	o.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		o.mtx.Lock()
+		delete(o.watchers, ch)
+		close(ch)
+		o.mtx.Unlock()
+	}()
+
+	return ch
+}
+
+// store sets the value and notifies the watchers. It must be called with
+// o.mtx held.
+func (o *Observable[T]) store(v T) bool {
+	if o.equal != nil && o.equal(o.value, v) {
+		return false
+	}
+
+	o.value = v
+	o.version++
+
+	c := Change[T]{Value: v, Version: o.version}
+	for ch := range o.watchers {
+		// The notifier is the only sender and holds the lock, so once a
+		// stale change is drained the send cannot block.
+		select {
+		case ch <- c:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- c
+		}
+	}
+
+	return true
+}
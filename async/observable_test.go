@@ -0,0 +1,92 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObservable_SetGet(t *testing.T) {
+	o := NewObservable(1)
+
+	assert.Equal(t, 1, o.Get())
+	assert.Equal(t, uint64(0), o.Version())
+
+	assert.True(t, o.Set(2))
+	assert.True(t, o.Set(2), "Expected every Set to be a change without an equality function")
+
+	assert.Equal(t, Change[int]{Value: 2, Version: 2}, o.Load())
+}
+
+func TestObservable_Equal(t *testing.T) {
+	o := NewObservable("a", WithEqual(func(a, b string) bool { return a == b }))
+
+	assert.False(t, o.Set("a"), "Expected no-op Set to be suppressed")
+	assert.Equal(t, uint64(0), o.Version())
+
+	v, changed := o.Update(func(s string) string { return s + "b" })
+	assert.True(t, changed)
+	assert.Equal(t, "ab", v)
+	assert.Equal(t, uint64(1), o.Version())
+}
+
+func TestObservable_Update(t *testing.T) {
+	o := NewObservable(0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.Update(func(v int) int { return v + 1 })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, o.Get())
+	assert.Equal(t, uint64(100), o.Version())
+}
+
+func TestObservable_Watch(t *testing.T) {
+	o := NewObservable(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := o.Watch(ctx)
+
+	assert.Equal(t, Change[int]{Value: 0, Version: 0}, <-ch, "Expected the current value first")
+
+	o.Set(1)
+	assert.Equal(t, Change[int]{Value: 1, Version: 1}, <-ch)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-ch
+		return !ok
+	}, time.Second, time.Millisecond, "Expected the channel to be closed")
+
+	o.Set(2)
+}
+
+func TestObservable_Coalesce(t *testing.T) {
+	o := NewObservable(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := o.Watch(ctx)
+
+	// Nobody reads while the value changes, only the latest is kept.
+	for i := 1; i <= 10; i++ {
+		o.Set(i)
+	}
+
+	assert.Equal(t, Change[int]{Value: 10, Version: 10}, <-ch)
+
+	select {
+	case c := <-ch:
+		t.Errorf("Unexpected change: %v", c)
+	default:
+	}
+}
@@ -0,0 +1,225 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pipeline stages below work on any typed channel, including EventChannel.
+// Every stage owns the channel it returns and closes it once its input is
+// exhausted or the context is cancelled, so no goroutine outlives either.
+// Consumers that stop reading early must cancel the context to release
+// the stage.
+
+// Merge forwards values from all input channels into a single channel.
+// The output is closed after every input has been closed or ctx is done.
+func Merge[T any](ctx context.Context, ins ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+
+	for _, in := range ins {
+		go func(in <-chan T) {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, in)
+				if !ok || !send(ctx, out, v) {
+					return
+				}
+			}
+		}(in)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Broadcast copies every value received from in to n output channels.
+// A value is handed to all outputs before the next one is read, so the
+// slowest consumer sets the pace of the whole group. A negative n counts
+// as zero: in is then drained and its values are discarded.
+func Broadcast[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+	n = max(n, 0)
+	outs := make([]chan T, n)
+	res := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		res[i] = outs[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			for _, out := range outs {
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return res
+}
+
+// Tee is a two-way Broadcast.
+func Tee[T any](ctx context.Context, in <-chan T) (<-chan T, <-chan T) {
+	outs := Broadcast(ctx, in, 2)
+	return outs[0], outs[1]
+}
+
+// Map applies fn to every value received from in.
+func Map[T, R any](ctx context.Context, in <-chan T, fn func(T) R) <-chan R {
+	out := make(chan R)
+
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, fn(v)) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Filter forwards only the values for which keep returns true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			if keep(v) && !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Buffer decouples a producer from a slow consumer by placing a queue of
+// the given size between them.
+func Buffer[T any](ctx context.Context, in <-chan T, size int) <-chan T {
+	out := make(chan T, size)
+
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok || !send(ctx, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Window groups values into batches. A batch is emitted as soon as it holds
+// size values or when interval has passed since its first value, whichever
+// comes first. A zero interval disables the time limit. The last, possibly
+// incomplete, batch is flushed when in is closed.
+func Window[T any](ctx context.Context, in <-chan T, size int, interval time.Duration) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+
+	out := make(chan []T)
+
+	go func() {
+		defer close(out)
+
+		var (
+			batch []T
+			timer *time.Timer
+			tick  <-chan time.Time
+		)
+
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, tick = nil, nil
+			}
+		}
+		defer stopTimer()
+
+		flush := func() bool {
+			stopTimer()
+			if len(batch) == 0 {
+				return true
+			}
+			b := batch
+			batch = nil
+			return send(ctx, out, b)
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) == 1 && interval > 0 {
+					timer = time.NewTimer(interval)
+					tick = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			case <-tick:
+				timer, tick = nil, nil
+				if !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// send delivers v to out unless ctx is done first.
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv reads the next value from in. It reports false when in is closed
+// or ctx is done.
+func recv[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+	case <-ctx.Done():
+	}
+	return v, ok
+}
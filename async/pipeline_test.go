@@ -0,0 +1,159 @@
+package async
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feed[T any](vals ...T) <-chan T {
+	ch := make(chan T, len(vals))
+	for _, v := range vals {
+		ch <- v
+	}
+	close(ch)
+	return ch
+}
+
+func drain[T any](ch <-chan T) []T {
+	var res []T
+	for v := range ch {
+		res = append(res, v)
+	}
+	return res
+}
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+
+	got := drain(Merge(ctx, feed(1, 2, 3), feed(4, 5), feed[int]()))
+	sort.Ints(got)
+
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, got, "Expected all values from all inputs")
+}
+
+func TestMerge_EventChannel(t *testing.T) {
+	ctx := context.Background()
+	eb := NewEventBus()
+
+	ch1 := make(EventChannel, 1)
+	ch2 := make(EventChannel, 1)
+	eb.Subscribe("a", ch1)
+	eb.Subscribe("b", ch2)
+
+	merged := Merge[EventData](ctx, ch1, ch2)
+
+	assert.NoError(t, eb.Publish("a", 1))
+	assert.NoError(t, eb.Publish("b", 2))
+
+	topics := []string{(<-merged).Topic, (<-merged).Topic}
+	sort.Strings(topics)
+	assert.Equal(t, []string{"a", "b"}, topics)
+}
+
+func TestBroadcast(t *testing.T) {
+	ctx := context.Background()
+
+	a, b := Tee(ctx, feed(1, 2, 3))
+
+	done := make(chan []int)
+	go func() { done <- drain(a) }()
+
+	assert.Equal(t, []int{1, 2, 3}, drain(b))
+	assert.Equal(t, []int{1, 2, 3}, <-done)
+
+	in := make(chan int)
+	assert.Empty(t, Broadcast(ctx, in, -1), "Expected no outputs for a negative count")
+	in <- 1
+	close(in)
+}
+
+func TestMapFilter(t *testing.T) {
+	ctx := context.Background()
+
+	even := Filter(ctx, feed(1, 2, 3, 4, 5, 6), func(v int) bool { return v%2 == 0 })
+	squares := Map(ctx, even, func(v int) int { return v * v })
+
+	assert.Equal(t, []int{4, 16, 36}, drain(squares))
+}
+
+func TestBuffer(t *testing.T) {
+	ctx := context.Background()
+
+	in := make(chan int)
+	out := Buffer(ctx, in, 3)
+
+	// The producer must not block while the buffer has room.
+	for i := 0; i < 4; i++ {
+		select {
+		case in <- i:
+		case <-time.After(time.Second):
+			t.Fatalf("Producer blocked on value %d", i)
+		}
+	}
+	close(in)
+
+	assert.Equal(t, []int{0, 1, 2, 3}, drain(out))
+}
+
+func TestWindow_Size(t *testing.T) {
+	ctx := context.Background()
+
+	got := drain(Window(ctx, feed(1, 2, 3, 4, 5), 2, 0))
+
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, got)
+}
+
+func TestWindow_Interval(t *testing.T) {
+	ctx := context.Background()
+
+	in := make(chan int)
+	out := Window(ctx, in, 10, 20*time.Millisecond)
+
+	in <- 1
+	in <- 2
+
+	select {
+	case batch := <-out:
+		assert.Equal(t, []int{1, 2}, batch, "Expected partial batch after interval")
+	case <-time.After(time.Second):
+		t.Fatal("Window did not flush on interval")
+	}
+
+	close(in)
+	_, ok := <-out
+	assert.False(t, ok, "Expected output to be closed")
+}
+
+func TestPipeline_Cancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Inputs are never closed, so only cancellation can stop the stages.
+	in1 := make(chan int)
+	in2 := make(chan int)
+
+	merged := Merge(ctx, in1, in2)
+	a, b := Tee(ctx, merged)
+	mapped := Map(ctx, a, func(v int) int { return v })
+	filtered := Filter(ctx, b, func(int) bool { return true })
+	buffered := Buffer(ctx, mapped, 4)
+	windowed := Window(ctx, filtered, 4, time.Millisecond)
+
+	cancel()
+
+	drain(buffered)
+	drain(windowed)
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "Expected all pipeline goroutines to exit")
+}
@@ -0,0 +1,101 @@
+package async
+
+// Priority is the publish-time priority of a message. Every subscriber
+// drains higher priorities first; messages of the same priority keep
+// their publishing order.
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// priorityLevels is the number of lanes every subscriber has.
+const priorityLevels = int(PriorityHigh) + 1
+
+// lane returns the lane index of the priority, clamping unknown values.
+func (p Priority) lane() int {
+	switch {
+	case p < PriorityLow:
+		return int(PriorityLow)
+	case p > PriorityHigh:
+		return int(PriorityHigh)
+	}
+	return int(p)
+}
+
+// lanes is a set of FIFO queues, one per priority.
+type lanes[T any] [priorityLevels]chan T
+
+func newLanes[T any](size int) lanes[T] {
+	var l lanes[T]
+	for i := range l {
+		l[i] = make(chan T, size)
+	}
+	return l
+}
+
+func (l *lanes[T]) close() {
+	for _, ch := range l {
+		close(ch)
+	}
+}
+
+// laneResult tells how receiveByPriority returned.
+type laneResult int
+
+const (
+	laneValue laneResult = iota
+	laneClosed
+	laneSignal
+)
+
+// receiveByPriority returns the next value from the highest priority lane
+// which has one, blocking until a value arrives, every lane is closed or
+// signal fires. Closed lanes are set to nil in l, so l must be the
+// receiver's own copy.
+func receiveByPriority[T any](l *lanes[T], signal <-chan struct{}) (T, laneResult) {
+	for i := priorityLevels - 1; i >= 0; i-- {
+		if l[i] == nil {
+			continue
+		}
+		select {
+		case v, ok := <-l[i]:
+			if ok {
+				return v, laneValue
+			}
+			l[i] = nil
+		default:
+		}
+	}
+
+	// Nothing is ready, wait for whichever lane gets a value first.
+	// The select is written out for the three priority levels.
+	for {
+		var zero T
+		if l[0] == nil && l[1] == nil && l[2] == nil {
+			return zero, laneClosed
+		}
+
+		select {
+		case v, ok := <-l[2]:
+			if ok {
+				return v, laneValue
+			}
+			l[2] = nil
+		case v, ok := <-l[1]:
+			if ok {
+				return v, laneValue
+			}
+			l[1] = nil
+		case v, ok := <-l[0]:
+			if ok {
+				return v, laneValue
+			}
+			l[0] = nil
+		case <-signal:
+			return zero, laneSignal
+		}
+	}
+}
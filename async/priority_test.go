@@ -0,0 +1,136 @@
+package async
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBus_PublishPriority(t *testing.T) {
+	bus := NewMessageBus(8)
+
+	release := make(chan struct{})
+	got := make(chan string, 10)
+
+	err := bus.Subscribe("topic", func(v string) {
+		if v == "block" {
+			<-release
+		}
+		got <- v
+	})
+	assert.NoError(t, err, "Expected no error when subscribing a valid handler")
+
+	assert.NoError(t, bus.Publish("topic", "block"))
+	assert.Eventually(t, func() bool {
+		return bus.Stats().Topics[0].Subscribers[0].QueueLen == 0
+	}, time.Second, time.Millisecond, "Expected the handler to be busy")
+
+	assert.NoError(t, bus.PublishPriority("topic", PriorityLow, "low"))
+	assert.NoError(t, bus.Publish("topic", "normal-1"))
+	assert.NoError(t, bus.Publish("topic", "normal-2"))
+	assert.NoError(t, bus.PublishPriority("topic", PriorityHigh, "high"))
+	assert.NoError(t, bus.PublishPriority("topic", Priority(42), "clamped"))
+
+	close(release)
+
+	var order []string
+	for i := 0; i < 6; i++ {
+		order = append(order, <-got)
+	}
+	assert.Equal(t, []string{"block", "high", "clamped", "normal-1", "normal-2", "low"}, order)
+}
+
+func TestMessageBus_PausePriority(t *testing.T) {
+	bus := NewMessageBus(8)
+
+	got := make(chan string, 10)
+	handler := func(v string) { got <- v }
+
+	assert.NoError(t, bus.Subscribe("topic", handler))
+	assert.NoError(t, bus.Pause("topic", handler, 2, DropOldest))
+
+	assert.NoError(t, bus.PublishPriority("topic", PriorityLow, "low"))
+	assert.NoError(t, bus.Publish("topic", "normal"))
+	assert.NoError(t, bus.PublishPriority("topic", PriorityHigh, "high"))
+	assert.Eventually(t, func() bool {
+		return bus.Stats().Topics[0].Subscribers[0].Dropped == 1
+	}, time.Second, time.Millisecond, "Expected the low priority message to be dropped")
+
+	assert.NoError(t, bus.Resume("topic", handler))
+	assert.Equal(t, "high", <-got)
+	assert.Equal(t, "normal", <-got)
+}
+
+func TestEventBus_PublishPriority(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 3)
+	eb.SubscribeWith("topic", ch, WithPriorities())
+
+	queueLen := func() int { return eb.Stats().Topics[0].Subscribers[0].QueueLen }
+	for _, v := range []string{"first", "second", "third"} {
+		assert.NoError(t, eb.Publish("topic", v))
+	}
+	assert.Eventually(t, func() bool { return len(ch) == 3 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, eb.PublishPriority("topic", "waiting", PriorityLow))
+	assert.Eventually(t, func() bool { return queueLen() == 3 }, time.Second, time.Millisecond,
+		"Expected the event to be waiting for room in the channel")
+
+	assert.NoError(t, eb.PublishPriority("topic", "low", PriorityLow))
+	assert.NoError(t, eb.Publish("topic", "normal"))
+	assert.NoError(t, eb.PublishPriority("topic", "high", PriorityHigh))
+	assert.Error(t, eb.PublishPriority("topic", "dropped", PriorityHigh), "Expected an error when the queue is full")
+	assert.Equal(t, 6, queueLen())
+
+	var order []string
+	for i := 0; i < 7; i++ {
+		ev := <-ch
+		order = append(order, ev.Data.(string))
+		if ev.Data == "high" {
+			assert.Equal(t, PriorityHigh, ev.Priority)
+		}
+	}
+	assert.Equal(t, []string{"first", "second", "third", "waiting", "high", "normal", "low"}, order)
+}
+
+func TestEventBus_PublishPriorityPlain(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 2)
+	eb.Subscribe("topic", ch)
+
+	assert.NoError(t, eb.PublishPriority("topic", "low", PriorityLow))
+	assert.NoError(t, eb.PublishPriority("topic", "high", PriorityHigh))
+	assert.Len(t, ch, 2, "Expected the events to be sent right away")
+	assert.Error(t, eb.Publish("topic", "dropped"), "Expected an error when the channel is full")
+
+	assert.Equal(t, "low", (<-ch).Data, "Expected the publishing order")
+	assert.Equal(t, "high", (<-ch).Data)
+
+	unbuffered := NewEventBus()
+	unbuffered.Subscribe("topic", make(EventChannel))
+	assert.Error(t, unbuffered.Publish("topic", 1), "Expected a drop without a reader")
+}
+
+func TestEventBus_PublishPriorityFIFO(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 4)
+	eb.Subscribe("topic", ch)
+
+	priorities := []Priority{PriorityLow, PriorityHigh, PriorityNormal, PriorityHigh}
+	for i, p := range priorities {
+		assert.NoError(t, eb.PublishPriority("topic", i, p))
+	}
+
+	for i, p := range priorities {
+		ev := <-ch
+		assert.Equal(t, i, ev.Data, "Expected the publishing order whatever the priority")
+		assert.Equal(t, p, ev.Priority)
+	}
+}
+
+func TestPriority_Lane(t *testing.T) {
+	assert.Equal(t, 0, Priority(-5).lane())
+	assert.Equal(t, 1, PriorityNormal.lane())
+	assert.Equal(t, priorityLevels-1, Priority(100).lane())
+}
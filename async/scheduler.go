@@ -0,0 +1,307 @@
+package async
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is the work run by the Scheduler. The context is cancelled when the
+// scheduler stops.
+type Job func(ctx context.Context)
+
+// EntryID identifies a scheduled job.
+type EntryID int
+
+// Entry describes a scheduled job.
+type Entry struct {
+	ID EntryID
+	// Spec is the spec the job was added with, empty for AddSchedule.
+	Spec string
+	// Next is the next activation time, zero if the job will not run again.
+	Next time.Time
+	// Prev is the last activation time, zero if the job has not run yet.
+	Prev time.Time
+	// Running is the number of job instances currently running.
+	Running int
+	// Skipped counts activations dropped because the job was still running.
+	Skipped uint64
+}
+
+// SchedulerOption configures a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithLocation sets the time zone cron specs are evaluated in.
+// The default is time.Local.
+func WithLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.loc = loc
+	}
+}
+
+// WithClock sets the clock used by the scheduler. The default is RealClock.
+func WithClock(c Clock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.clock = c
+	}
+}
+
+// EntryOption configures a single scheduled job.
+type EntryOption func(*entry)
+
+// WithJitter delays every activation by a random duration in [0, d).
+func WithJitter(d time.Duration) EntryOption {
+	return func(e *entry) {
+		e.jitter = d
+	}
+}
+
+// AllowOverlap lets a job start while its previous run is still running.
+// By default such activations are skipped.
+func AllowOverlap() EntryOption {
+	return func(e *entry) {
+		e.allowOverlap = true
+	}
+}
+
+type entry struct {
+	Entry
+	schedule     Schedule
+	job          Job
+	jitter       time.Duration
+	allowOverlap bool
+}
+
+// Scheduler runs jobs on cron schedules or fixed intervals.
+// It is safe for concurrent use; jobs may be added and removed while the
+// scheduler is running.
+type Scheduler struct {
+	mtx     sync.Mutex
+	clock   Clock
+	loc     *time.Location
+	entries map[EntryID]*entry
+	lastID  EntryID
+	rnd     *rand.Rand
+	wake    chan struct{}
+}
+
+// NewScheduler creates a new Scheduler. Call Run to start it.
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		clock:   RealClock,
+		loc:     time.Local,
+		entries: make(map[EntryID]*entry),
+		wake:    make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.rnd = rand.New(rand.NewSource(s.clock.Now().UnixNano())) // #nosec G404 -- jitter does not need a secure source.
+
+	return s
+}
+
+// Add schedules job using a spec accepted by ParseSchedule.
+func (s *Scheduler) Add(spec string, job Job, opts ...EntryOption) (EntryID, error) {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return 0, err
+	}
+	return s.add(spec, sched, job, opts), nil
+}
+
+// AddSchedule schedules job using a custom Schedule, e.g. Every.
+func (s *Scheduler) AddSchedule(sched Schedule, job Job, opts ...EntryOption) EntryID {
+	return s.add("", sched, job, opts)
+}
+
+// AddPublish schedules a publication of data() on the bus topic. When data
+// is nil the activation time is published instead. Publish errors, such as
+// no subscribers, are ignored.
+func (s *Scheduler) AddPublish(spec string, bus EventBus, topic string, data func() any, opts ...EntryOption) (EntryID, error) {
+	return s.Add(spec, func(context.Context) {
+		var v any
+		if data != nil {
+			v = data()
+		} else {
+			v = s.clock.Now().In(s.loc)
+		}
+		//#nosec G104 -- Nobody to report the error to.
+		bus.Publish(topic, v)
+	}, opts...)
+}
+
+func (s *Scheduler) add(spec string, sched Schedule, job Job, opts []EntryOption) EntryID {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.lastID++
+	e := &entry{
+		Entry:    Entry{ID: s.lastID, Spec: spec},
+		schedule: sched,
+		job:      job,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.Next = sched.Next(s.now())
+	s.entries[e.ID] = e
+
+	s.notify()
+	return e.ID
+}
+
+// Remove removes the job. Running instances are not interrupted.
+// It returns false if there is no such job.
+func (s *Scheduler) Remove(id EntryID) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.entries[id]; !ok {
+		return false
+	}
+	delete(s.entries, id)
+
+	s.notify()
+	return true
+}
+
+// Entry returns the state of a single job.
+func (s *Scheduler) Entry(id EntryID) (Entry, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if e, ok := s.entries[id]; ok {
+		return e.Entry, true
+	}
+	return Entry{}, false
+}
+
+// Entries returns the state of all jobs ordered by their next activation.
+// Jobs which will not run again come last.
+func (s *Scheduler) Entries() []Entry {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	res := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		res = append(res, e.Entry)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i].Next, res[j].Next
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// Run runs the scheduler until ctx is done, then waits for the running
+// jobs to return. It must not be called concurrently.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		s.mtx.Lock()
+		now := s.now()
+		var next time.Time
+		for _, e := range s.entries {
+			if e.Next.IsZero() {
+				continue
+			}
+			if !e.Next.After(now) {
+				s.fire(ctx, &wg, e)
+				e.Prev = e.Next
+				e.Next = e.schedule.Next(now)
+			}
+			if !e.Next.IsZero() && (next.IsZero() || e.Next.Before(next)) {
+				next = e.Next
+			}
+		}
+		s.mtx.Unlock()
+
+		var (
+			timer Timer
+			fired <-chan time.Time
+		)
+		if !next.IsZero() {
+			timer = s.clock.NewTimer(next.Sub(now))
+			fired = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-fired:
+		case <-s.wake:
+			// A new timer is set for the updated entries.
+			stopTimer(timer)
+		}
+	}
+}
+
+// fire starts the job unless its previous run is still going and overlap
+// is not allowed. It must be called with s.mtx held.
+func (s *Scheduler) fire(ctx context.Context, wg *sync.WaitGroup, e *entry) {
+	if e.Running > 0 && !e.allowOverlap {
+		e.Skipped++
+		return
+	}
+
+	var delay time.Duration
+	if e.jitter > 0 {
+		delay = time.Duration(s.rnd.Int63n(int64(e.jitter)))
+	}
+
+	e.Running++
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer func() {
+			s.mtx.Lock()
+			e.Running--
+			s.mtx.Unlock()
+		}()
+
+		if delay > 0 {
+			timer := s.clock.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		e.job(ctx)
+	}()
+}
+
+// stopTimer stops t unless it is nil.
+func stopTimer(t Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// now returns the clock time in the scheduler location.
+func (s *Scheduler) now() time.Time {
+	return s.clock.Now().In(s.loc)
+}
+
+// notify wakes up Run to recompute the next activation.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,223 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForWaiters blocks until n goroutines are waiting on the fake clock.
+func waitForWaiters(t *testing.T, c *FakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Waiters() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d clock waiters, got %d", n, c.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func startScheduler(s *Scheduler) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func TestScheduler_Cron(t *testing.T) {
+	start := time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	s := NewScheduler(WithClock(clock), WithLocation(time.UTC))
+
+	runs := make(chan time.Time, 10)
+	id, err := s.Add("*/5 * * * *", func(context.Context) { runs <- clock.Now() })
+	assert.NoError(t, err)
+
+	e, ok := s.Entry(id)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(5*time.Minute), e.Next, "Expected next run to be introspectable")
+
+	stop := startScheduler(s)
+	defer stop()
+
+	for i := 1; i <= 3; i++ {
+		waitForWaiters(t, clock, 1)
+		clock.Advance(5 * time.Minute)
+		assert.Equal(t, start.Add(time.Duration(i)*5*time.Minute), <-runs)
+	}
+
+	e, _ = s.Entry(id)
+	assert.Equal(t, start.Add(15*time.Minute), e.Prev)
+	assert.Equal(t, start.Add(20*time.Minute), e.Next)
+}
+
+func TestScheduler_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	start := time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	s := NewScheduler(WithClock(clock), WithLocation(loc))
+
+	id, err := s.Add("0 9 * * *", func(context.Context) {})
+	assert.NoError(t, err)
+
+	e, _ := s.Entry(id)
+	assert.Equal(t, time.Date(2024, 3, 22, 6, 0, 0, 0, time.UTC), e.Next.UTC())
+}
+
+func TestScheduler_Publish(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+	eb := NewEventBus()
+	ch := make(EventChannel, 1)
+	eb.Subscribe("tick", ch)
+
+	_, err := s.AddPublish("@every 1m", eb, "tick", func() any { return "ping" })
+	assert.NoError(t, err)
+
+	stop := startScheduler(s)
+	defer stop()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Minute)
+
+	ev := <-ch
+	assert.Equal(t, "ping", ev.Data)
+	assert.Equal(t, "tick", ev.Topic)
+}
+
+func TestScheduler_Overlap(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	id := s.AddSchedule(Every(time.Second), func(context.Context) {
+		started <- struct{}{}
+		<-release
+	})
+
+	stop := startScheduler(s)
+	defer stop()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Second)
+	<-started
+
+	// The job is still running, the next activation has to be skipped.
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Second)
+	waitForWaiters(t, clock, 1)
+
+	e, _ := s.Entry(id)
+	assert.Equal(t, 1, e.Running)
+	assert.Equal(t, uint64(1), e.Skipped)
+
+	close(release)
+}
+
+func TestScheduler_Wake(t *testing.T) {
+	start := time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	s := NewScheduler(WithClock(clock))
+
+	runs := make(chan time.Time, 10)
+	s.AddSchedule(Every(time.Minute), func(context.Context) { runs <- clock.Now() })
+
+	stop := startScheduler(s)
+	defer stop()
+
+	waitForWaiters(t, clock, 1)
+	for i := 0; i < 3; i++ {
+		s.Remove(s.AddSchedule(Every(time.Hour), func(context.Context) {}))
+	}
+	assert.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond,
+		"Expected the earlier timers to be stopped")
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), <-runs)
+}
+
+func TestFakeClock_Stop(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC))
+	timer := clock.NewTimer(time.Second)
+	assert.Equal(t, 1, clock.Waiters())
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	assert.Zero(t, clock.Waiters())
+
+	clock.Advance(time.Second)
+	assert.Empty(t, timer.C(), "Expected a stopped timer not to fire")
+
+	fired := clock.NewTimer(time.Second)
+	clock.Advance(time.Second)
+	assert.Len(t, fired.C(), 1)
+	assert.False(t, fired.Stop())
+}
+
+func TestScheduler_Jitter(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock))
+
+	var (
+		mtx  sync.Mutex
+		runs int
+	)
+	s.AddSchedule(Every(time.Minute), func(context.Context) {
+		mtx.Lock()
+		runs++
+		mtx.Unlock()
+	}, WithJitter(time.Second))
+
+	stop := startScheduler(s)
+	defer stop()
+
+	waitForWaiters(t, clock, 1)
+	clock.Advance(time.Minute)
+
+	// Both the scheduler and the delayed job wait on the clock now.
+	waitForWaiters(t, clock, 2)
+	mtx.Lock()
+	assert.Equal(t, 0, runs, "Expected the job to be delayed by the jitter")
+	mtx.Unlock()
+
+	clock.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return runs == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestScheduler_Entries(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 3, 22, 10, 0, 0, 0, time.UTC))
+	s := NewScheduler(WithClock(clock), WithLocation(time.UTC))
+
+	daily, err := s.Add("@daily", func(context.Context) {})
+	assert.NoError(t, err)
+	hourly, err := s.Add("@hourly", func(context.Context) {})
+	assert.NoError(t, err)
+	never, err := s.Add("0 0 30 feb *", func(context.Context) {})
+	assert.NoError(t, err)
+
+	_, err = s.Add("bad spec", func(context.Context) {})
+	assert.Error(t, err)
+
+	entries := s.Entries()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, []EntryID{hourly, daily, never}, []EntryID{entries[0].ID, entries[1].ID, entries[2].ID})
+	assert.Equal(t, "@hourly", entries[0].Spec)
+
+	assert.True(t, s.Remove(hourly))
+	assert.False(t, s.Remove(hourly))
+	assert.Len(t, s.Entries(), 2)
+}
@@ -0,0 +1,143 @@
+package async
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// dropLogSize is the number of recent drops remembered by a bus.
+const dropLogSize = 64
+
+// SubscriberStats describes the state of a single subscription.
+type SubscriberStats struct {
+	// ID is the subscription ID. MessageBus subscriptions have none.
+	ID uint64 `json:"id,omitempty"`
+	// Handler is the name of the handler function (MessageBus only).
+	Handler string `json:"handler,omitempty"`
+	// QueueLen is the number of messages waiting for delivery.
+	QueueLen int `json:"queue_len"`
+	// QueueCap is the capacity of the subscriber queue.
+	QueueCap int `json:"queue_cap"`
+	// Dropped is the number of messages dropped for this subscriber.
+	Dropped uint64 `json:"dropped"`
+	// Paused reports whether delivery is paused (MessageBus only).
+	Paused bool `json:"paused,omitempty"`
+	// Pending is the number of messages buffered while paused.
+	Pending int `json:"pending,omitempty"`
+}
+
+// TopicStats describes the state of a single topic.
+type TopicStats struct {
+	Topic       string            `json:"topic"`
+	Subscribers []SubscriberStats `json:"subscribers"`
+	// Retained is the last value published to the topic, nil if none or
+	// if the bus was created without WithRetention. For MessageBus it holds
+	// the published arguments as []interface{}.
+	Retained any `json:"retained,omitempty"`
+}
+
+// DropRecord describes a message that was not delivered to a subscriber.
+type DropRecord struct {
+	Topic          string    `json:"topic"`
+	SubscriptionID uint64    `json:"subscription_id,omitempty"`
+	Time           time.Time `json:"time"`
+}
+
+// BusStats is a point in time snapshot of a bus.
+type BusStats struct {
+	Topics      []TopicStats `json:"topics"`
+	RecentDrops []DropRecord `json:"recent_drops"`
+}
+
+// BusOption configures a MessageBus or an EventBus.
+type BusOption func(*busState)
+
+// WithRetention keeps the last value published to every topic while it has
+// subscribers, to be shown by Stats. The value is forgotten when the last
+// subscriber of the topic leaves.
+func WithRetention() BusOption {
+	return func(s *busState) {
+		s.retention = true
+	}
+}
+
+// busState keeps the bookkeeping shared by both bus implementations that is
+// not needed for delivery itself: retained values and recent drops.
+type busState struct {
+	retention bool
+
+	mtx      sync.Mutex
+	retained map[string]any
+	drops    []DropRecord
+	next     int
+}
+
+func newBusState(opts []BusOption) *busState {
+	s := &busState{
+		retained: make(map[string]any),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// retain stores the last value published to the topic, if enabled.
+func (s *busState) retain(topic string, v any) {
+	if !s.retention {
+		return
+	}
+	s.mtx.Lock()
+	s.retained[topic] = v
+	s.mtx.Unlock()
+}
+
+// forget removes the retained value of the topic.
+func (s *busState) forget(topic string) {
+	if !s.retention {
+		return
+	}
+	s.mtx.Lock()
+	delete(s.retained, topic)
+	s.mtx.Unlock()
+}
+
+// drop records a message which was not delivered.
+func (s *busState) drop(topic string, subscriptionID uint64) {
+	rec := DropRecord{Topic: topic, SubscriptionID: subscriptionID, Time: time.Now()}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(s.drops) < dropLogSize {
+		s.drops = append(s.drops, rec)
+		return
+	}
+	s.drops[s.next] = rec
+	s.next = (s.next + 1) % dropLogSize
+}
+
+// snapshot merges the per topic subscriber stats with the retained values
+// and the drop log. The result is sorted by topic, drops are oldest first.
+func (s *busState) snapshot(subs map[string][]SubscriberStats) BusStats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	topics := make([]TopicStats, 0, len(subs))
+	for topic, ss := range subs {
+		topics = append(topics, TopicStats{Topic: topic, Subscribers: ss, Retained: s.retained[topic]})
+	}
+	for topic, v := range s.retained {
+		if _, ok := subs[topic]; !ok {
+			topics = append(topics, TopicStats{Topic: topic, Retained: v})
+		}
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Topic < topics[j].Topic })
+
+	drops := make([]DropRecord, 0, len(s.drops))
+	drops = append(drops, s.drops[s.next:]...)
+	drops = append(drops, s.drops[:s.next]...)
+
+	return BusStats{Topics: topics, RecentDrops: drops}
+}
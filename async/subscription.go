@@ -0,0 +1,106 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+// SubscribeOption configures a subscription, e.g. limits its lifetime.
+// Limited subscriptions remove themselves from the bus once they expire.
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	maxDeliveries int
+	ttl           time.Duration
+	deadline      time.Time
+	priorities    bool
+}
+
+// WithPriorities delivers the events of an EventBus subscription by
+// priority: they are queued and a goroutine forwards them to the channel,
+// higher priorities first. Without it events are sent to the channel in
+// publishing order. MessageBus handlers always receive messages by
+// priority.
+func WithPriorities() SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.priorities = true
+	}
+}
+
+// WithMaxDeliveries removes the subscription after n deliveries.
+func WithMaxDeliveries(n int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.maxDeliveries = n
+	}
+}
+
+// WithTTL removes the subscription d after it was made.
+func WithTTL(d time.Duration) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.ttl = d
+	}
+}
+
+// WithDeadline removes the subscription at t.
+func WithDeadline(t time.Time) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.deadline = t
+	}
+}
+
+func newSubscribeConfig(opts []SubscribeOption) subscribeConfig {
+	var c subscribeConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// limited reports whether the subscription expires at all.
+func (c subscribeConfig) limited() bool {
+	return c.maxDeliveries > 0 || c.ttl > 0 || !c.deadline.IsZero()
+}
+
+// expireAfter calls fn when the TTL or the deadline, whichever is earlier,
+// has passed. It returns nil if neither is set.
+func (c subscribeConfig) expireAfter(fn func()) *time.Timer {
+	var d time.Duration
+	switch {
+	case c.ttl > 0 && !c.deadline.IsZero():
+		d = c.ttl
+		if until := time.Until(c.deadline); until < d {
+			d = until
+		}
+	case c.ttl > 0:
+		d = c.ttl
+	case !c.deadline.IsZero():
+		d = time.Until(c.deadline)
+	default:
+		return nil
+	}
+	return time.AfterFunc(d, fn)
+}
+
+// waitForQueueSize is the number of events a WaitFor subscription buffers.
+const waitForQueueSize = 64
+
+// WaitFor blocks until an event matching the predicate is published on the
+// topic or ctx is done. A nil predicate matches any event. The subscription
+// buffers up to 64 events while the predicate runs; beyond that events are
+// dropped for it, and Publish reports them, like for any full subscriber.
+func WaitFor(ctx context.Context, bus EventBus, topic string, predicate func(EventData) bool) (EventData, error) {
+	ch := make(EventChannel, waitForQueueSize)
+	id := bus.Subscribe(topic, ch)
+	defer bus.Unsubscribe(topic, id)
+
+	for {
+		select {
+		case ev := <-ch:
+			if predicate == nil || predicate(ev) {
+				return ev, nil
+			}
+		case <-ctx.Done():
+			return EventData{}, ctx.Err()
+		}
+	}
+}
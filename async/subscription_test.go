@@ -0,0 +1,187 @@
+package async
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBus_SubscribeOnce(t *testing.T) {
+	bus := NewMessageBus(4)
+
+	var calls atomic.Int32
+	assert.NoError(t, bus.SubscribeOnce("topic", func(v int) { calls.Add(1) }))
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, bus.Publish("topic", i))
+	}
+
+	assert.Eventually(t, func() bool {
+		return subscriberCount(bus) == 0
+	}, time.Second, time.Millisecond, "Expected the subscription to be removed")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMessageBus_MaxDeliveries(t *testing.T) {
+	bus := NewMessageBus(4)
+
+	var calls atomic.Int32
+	assert.NoError(t, bus.SubscribeWith("topic", func() { calls.Add(1) }, WithMaxDeliveries(2)))
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, bus.Publish("topic"))
+	}
+
+	assert.Eventually(t, func() bool {
+		return subscriberCount(bus) == 0
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestMessageBus_TTL(t *testing.T) {
+	bus := NewMessageBus(4)
+
+	fn := func() {}
+	assert.NoError(t, bus.SubscribeWith("topic", fn, WithTTL(10*time.Millisecond)))
+	assert.Equal(t, 1, subscriberCount(bus))
+
+	assert.Eventually(t, func() bool {
+		return subscriberCount(bus) == 0
+	}, time.Second, time.Millisecond, "Expected the subscription to expire")
+
+	assert.Error(t, bus.Unsubscribe("topic", fn), "Expected the handler to be gone")
+}
+
+func TestEventBus_SubscribeOnce(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 4)
+	eb.SubscribeOnce("topic", ch)
+
+	assert.NoError(t, eb.Publish("topic", 1))
+
+	ev, ok := <-ch
+	assert.True(t, ok)
+	assert.Equal(t, 1, ev.Data)
+
+	_, ok = <-ch
+	assert.False(t, ok, "Expected the channel to be closed")
+
+	assert.Eventually(t, func() bool {
+		return subscriberCount(eb) == 0
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, eb.Publish("topic", 2), "Expected no subscribers left")
+}
+
+func TestEventBus_Deadline(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 4)
+	eb.SubscribeWith("topic", ch, WithTTL(time.Hour), WithDeadline(time.Now().Add(10*time.Millisecond)))
+
+	select {
+	case _, ok := <-ch:
+		assert.False(t, ok, "Expected the channel to be closed")
+	case <-time.After(time.Second):
+		t.Fatal("Expected the subscription to expire at the deadline")
+	}
+
+	assert.Zero(t, subscriberCount(eb))
+}
+
+func TestEventBus_UnsubscribeLimited(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel)
+	id := eb.SubscribeWith("topic", ch, WithMaxDeliveries(10))
+
+	eb.Unsubscribe("topic", id)
+
+	_, ok := <-ch
+	assert.False(t, ok, "Expected the channel to be closed")
+}
+
+func TestWaitFor(t *testing.T) {
+	eb := NewEventBus()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = eb.Publish("topic", i)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ev, err := WaitFor(ctx, eb, "topic", func(ev EventData) bool { return ev.Data.(int) >= 5 })
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, ev.Data.(int), 5)
+}
+
+func TestWaitFor_Timeout(t *testing.T) {
+	eb := NewEventBus()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := WaitFor(ctx, eb, "topic", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, subscriberCount(eb), "Expected WaitFor to unsubscribe")
+}
+
+func TestWaitFor_ReusedID(t *testing.T) {
+	eb := NewEventBus()
+	a := eb.Subscribe("topic", make(EventChannel, 1))
+	b := make(EventChannel, 1)
+	eb.Subscribe("topic", b)
+	eb.Unsubscribe("topic", a)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := WaitFor(ctx, eb, "topic", nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Equal(t, 1, subscriberCount(eb), "Expected WaitFor to remove its own subscription only")
+	assert.NoError(t, eb.Publish("topic", 1))
+	assert.Len(t, b, 1, "Expected the other subscription to be kept")
+	assert.Error(t, eb.Publish("topic", 2), "Expected only the full channel of the other subscription")
+}
+
+func TestWaitFor_Burst(t *testing.T) {
+	eb := NewEventBus()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res := make(chan EventData, 1)
+	go func() {
+		ev, err := WaitFor(ctx, eb, "topic", func(ev EventData) bool { return ev.Data == 20 })
+		assert.NoError(t, err)
+		res <- ev
+	}()
+	assert.Eventually(t, func() bool { return subscriberCount(eb) == 1 }, time.Second, time.Millisecond)
+
+	for i := 1; i <= 20; i++ {
+		assert.NoError(t, eb.Publish("topic", i), "Expected room for a burst of events")
+	}
+	select {
+	case ev := <-res:
+		assert.Equal(t, 20, ev.Data)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the matching event to be received")
+	}
+}
+
+func subscriberCount(bus StatsProvider) (n int) {
+	for _, ts := range bus.Stats().Topics {
+		n += len(ts.Subscribers)
+	}
+	return n
+}
@@ -0,0 +1,413 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var ErrTooManyRestarts = errors.New("supervisor: too many restarts")
+
+// Worker is a child run by the Supervisor. It should return when ctx is done.
+type Worker func(ctx context.Context) error
+
+// Strategy tells the Supervisor which children to restart when one exits.
+type Strategy int
+
+const (
+	// OneForOne restarts only the child which exited.
+	OneForOne Strategy = iota
+	// OneForAll stops all other children in reverse order and restarts
+	// all of them in order.
+	OneForAll
+)
+
+// RestartPolicy tells the Supervisor when a child should be restarted.
+type RestartPolicy int
+
+const (
+	// Permanent children are always restarted.
+	Permanent RestartPolicy = iota
+	// Transient children are restarted only if they fail or panic.
+	Transient
+	// Temporary children are never restarted.
+	Temporary
+)
+
+// SupervisorEventType is the kind of a lifecycle event.
+type SupervisorEventType int
+
+const (
+	ChildStarted SupervisorEventType = iota
+	ChildExited
+	ChildPanicked
+	ChildRestarting
+	ChildStopped
+	SupervisorGaveUp
+)
+
+// String implements the fmt.Stringer interface.
+func (t SupervisorEventType) String() string {
+	switch t {
+	case ChildStarted:
+		return "started"
+	case ChildExited:
+		return "exited"
+	case ChildPanicked:
+		return "panicked"
+	case ChildRestarting:
+		return "restarting"
+	case ChildStopped:
+		return "stopped"
+	case SupervisorGaveUp:
+		return "gave up"
+	}
+	return fmt.Sprintf("SupervisorEventType(%d)", int(t))
+}
+
+// SupervisorEvent describes a change in the life of a child.
+type SupervisorEvent struct {
+	Type  SupervisorEventType
+	Child string
+	// Err is the error the child exited with, if any.
+	Err error
+	// Delay is the backoff before a restart.
+	Delay time.Duration
+	Time  time.Time
+}
+
+// PanicError is reported when a child panics.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// SupervisorOption configures a Supervisor.
+type SupervisorOption func(*Supervisor)
+
+// WithStrategy sets the restart strategy. The default is OneForOne.
+func WithStrategy(strategy Strategy) SupervisorOption {
+	return func(s *Supervisor) {
+		s.strategy = strategy
+	}
+}
+
+// WithRestartIntensity makes the supervisor give up when more than max
+// restarts happen within the window. A max below 1 disables the limit.
+// The default is 10 restarts per minute.
+func WithRestartIntensity(max int, window time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.maxRestarts = max
+		s.window = window
+	}
+}
+
+// WithBackoff sets the delay before a restart. It starts at min and doubles
+// with every consecutive restart of a child up to max. A child which ran for
+// longer than the restart window starts over from min.
+// The default is 100ms up to 10s.
+func WithBackoff(min, max time.Duration) SupervisorOption {
+	return func(s *Supervisor) {
+		s.minBackoff = min
+		s.maxBackoff = max
+	}
+}
+
+// WithEventHandler sets the function lifecycle events are reported to.
+// It is called synchronously from the supervisor goroutine.
+func WithEventHandler(fn func(SupervisorEvent)) SupervisorOption {
+	return func(s *Supervisor) {
+		s.onEvent = fn
+	}
+}
+
+// WithEventBus publishes lifecycle events on the bus topic.
+func WithEventBus(bus EventBus, topic string) SupervisorOption {
+	return WithEventHandler(func(ev SupervisorEvent) {
+		//#nosec G104 -- Events are best effort.
+		bus.Publish(topic, ev)
+	})
+}
+
+// ChildOption configures a child.
+type ChildOption func(*child)
+
+// WithRestartPolicy sets the restart policy of the child.
+// The default is Permanent.
+func WithRestartPolicy(policy RestartPolicy) ChildOption {
+	return func(c *child) {
+		c.policy = policy
+	}
+}
+
+type child struct {
+	name     string
+	worker   Worker
+	policy   RestartPolicy
+	gen      int
+	running  bool
+	started  time.Time
+	restarts int
+	cancel   context.CancelFunc
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+type childExit struct {
+	c   *child
+	gen int
+	err error
+}
+
+// Supervisor runs child workers and restarts them when they exit.
+type Supervisor struct {
+	strategy    Strategy
+	maxRestarts int
+	window      time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	onEvent     func(SupervisorEvent)
+	children    []*child
+	restarts    []time.Time
+	exits       chan childExit
+}
+
+// NewSupervisor creates a new Supervisor.
+func NewSupervisor(opts ...SupervisorOption) *Supervisor {
+	s := &Supervisor{
+		strategy:    OneForOne,
+		maxRestarts: 10,
+		window:      time.Minute,
+		minBackoff:  100 * time.Millisecond,
+		maxBackoff:  10 * time.Second,
+		onEvent:     func(SupervisorEvent) {},
+		exits:       make(chan childExit),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Add registers a child. Children are started in the order they were added
+// and stopped in reverse order. Add must not be called after Run.
+func (s *Supervisor) Add(name string, w Worker, opts ...ChildOption) {
+	c := &child{name: name, worker: w, policy: Permanent}
+	for _, opt := range opts {
+		opt(c)
+	}
+	s.children = append(s.children, c)
+}
+
+// Run starts the children and supervises them until ctx is done, then
+// stops them in reverse order. Children get a context which carries the
+// values of ctx but is cancelled only when it is their turn to stop.
+// Run returns nil on cancellation or when no child is left running, and
+// an error wrapping ErrTooManyRestarts when the restart intensity is
+// exceeded.
+func (s *Supervisor) Run(ctx context.Context) error {
+	base := context.WithoutCancel(ctx)
+
+	for _, c := range s.children {
+		s.start(base, c)
+	}
+
+	for {
+		if !s.anyRunning() {
+			return nil
+		}
+
+		var ex childExit
+		select {
+		case <-ctx.Done():
+			s.stopAll()
+			return nil
+		case ex = <-s.exits:
+		}
+
+		c := ex.c
+		if ex.gen != c.gen {
+			continue
+		}
+		s.exited(c, ex.err)
+
+		if !shouldRestart(c.policy, ex.err) {
+			continue
+		}
+
+		if !s.allowRestart() {
+			s.emit(SupervisorEvent{Type: SupervisorGaveUp, Child: c.name, Err: ex.err})
+			s.stopAll()
+			if ex.err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrTooManyRestarts, c.name, ex.err)
+			}
+			return fmt.Errorf("%w: %s", ErrTooManyRestarts, c.name)
+		}
+
+		restart := []*child{c}
+		if s.strategy == OneForAll {
+			restart = restart[:0]
+			for _, other := range s.children {
+				if other == c || other.running && other.policy != Temporary {
+					restart = append(restart, other)
+				}
+			}
+			s.stopAll()
+		}
+
+		delay := s.backoff(c)
+		for _, r := range restart {
+			s.emit(SupervisorEvent{Type: ChildRestarting, Child: r.name, Delay: delay})
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				s.stopAll()
+				return nil
+			case <-timer.C:
+			}
+		}
+
+		for _, r := range restart {
+			s.start(base, r)
+		}
+	}
+}
+
+// start runs a new generation of the child.
+func (s *Supervisor) start(base context.Context, c *child) {
+	ctx, cancel := context.WithCancel(base)
+
+	c.gen++
+	c.running = true
+	c.started = time.Now()
+	c.cancel = cancel
+	c.stopping = make(chan struct{})
+	c.done = make(chan struct{})
+
+	gen, stopping, done := c.gen, c.stopping, c.done
+
+	s.emit(SupervisorEvent{Type: ChildStarted, Child: c.name})
+
+	go func() {
+		defer close(done)
+		err := runWorker(ctx, c.worker)
+		cancel()
+		select {
+		case s.exits <- childExit{c: c, gen: gen, err: err}:
+		case <-stopping:
+		}
+	}()
+}
+
+// stopAll stops the running children in reverse order, waiting for each
+// one to return before stopping the previous one.
+func (s *Supervisor) stopAll() {
+	for i := len(s.children) - 1; i >= 0; i-- {
+		c := s.children[i]
+		if !c.running {
+			continue
+		}
+		close(c.stopping)
+		c.cancel()
+		<-c.done
+		c.running = false
+		s.emit(SupervisorEvent{Type: ChildStopped, Child: c.name})
+	}
+}
+
+// exited records the exit of a child.
+func (s *Supervisor) exited(c *child, err error) {
+	c.running = false
+
+	if time.Since(c.started) > s.window {
+		c.restarts = 0
+	}
+	c.restarts++
+
+	ev := SupervisorEvent{Type: ChildExited, Child: c.name, Err: err}
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		ev.Type = ChildPanicked
+	}
+	s.emit(ev)
+}
+
+// allowRestart records a restart and checks the restart intensity.
+func (s *Supervisor) allowRestart() bool {
+	if s.maxRestarts < 1 {
+		return true
+	}
+
+	now := time.Now()
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.window {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = append(recent, now)
+
+	return len(s.restarts) <= s.maxRestarts
+}
+
+// backoff returns the delay before restarting the child.
+func (s *Supervisor) backoff(c *child) time.Duration {
+	if c.restarts < 1 || s.minBackoff <= 0 {
+		return 0
+	}
+
+	delay := s.minBackoff
+	for i := 1; i < c.restarts && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	return delay
+}
+
+func (s *Supervisor) anyRunning() bool {
+	for _, c := range s.children {
+		if c.running {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Supervisor) emit(ev SupervisorEvent) {
+	ev.Time = time.Now()
+	s.onEvent(ev)
+}
+
+// shouldRestart applies the restart policy to the exit error.
+func shouldRestart(policy RestartPolicy, err error) bool {
+	switch policy {
+	case Permanent:
+		return true
+	case Transient:
+		return err != nil
+	}
+	return false
+}
+
+// runWorker runs the worker converting a panic into a PanicError.
+func runWorker(ctx context.Context, w Worker) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return w(ctx)
+}
@@ -0,0 +1,238 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// eventLog collects supervisor events.
+type eventLog struct {
+	mtx    sync.Mutex
+	events []SupervisorEvent
+}
+
+func (l *eventLog) add(ev SupervisorEvent) {
+	l.mtx.Lock()
+	l.events = append(l.events, ev)
+	l.mtx.Unlock()
+}
+
+func (l *eventLog) list(typ SupervisorEventType) []string {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	var res []string
+	for _, ev := range l.events {
+		if ev.Type == typ {
+			res = append(res, ev.Child)
+		}
+	}
+	return res
+}
+
+func TestSupervisor_RestartPanic(t *testing.T) {
+	var log eventLog
+	s := NewSupervisor(WithBackoff(time.Millisecond, time.Millisecond), WithEventHandler(log.add))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := 0
+	s.Add("flaky", func(ctx context.Context) error {
+		runs++
+		if runs < 3 {
+			panic("boom")
+		}
+		<-ctx.Done()
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		return len(log.list(ChildStarted)) == 3
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, []string{"flaky", "flaky"}, log.list(ChildPanicked))
+	assert.Equal(t, []string{"flaky"}, log.list(ChildStopped))
+}
+
+func TestSupervisor_OneForOne(t *testing.T) {
+	var log eventLog
+	s := NewSupervisor(WithBackoff(0, 0), WithEventHandler(log.add))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fail := make(chan struct{})
+	s.Add("a", func(ctx context.Context) error { <-ctx.Done(); return nil })
+	s.Add("b", func(ctx context.Context) error {
+		select {
+		case <-fail:
+			return errors.New("failed")
+		case <-ctx.Done():
+			return nil
+		}
+	})
+
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	fail <- struct{}{}
+	assert.Eventually(t, func() bool {
+		return len(log.list(ChildStarted)) == 3
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Equal(t, []string{"a", "b", "b"}, log.list(ChildStarted))
+	assert.Equal(t, []string{"b"}, log.list(ChildRestarting))
+	assert.Equal(t, []string{"b", "a"}, log.list(ChildStopped), "Expected children to stop in reverse order")
+}
+
+func TestSupervisor_OneForAll(t *testing.T) {
+	var log eventLog
+	s := NewSupervisor(WithStrategy(OneForAll), WithBackoff(0, 0), WithEventHandler(log.add))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fail := make(chan struct{})
+	block := func(ctx context.Context) error { <-ctx.Done(); return nil }
+	s.Add("a", block)
+	s.Add("b", func(ctx context.Context) error {
+		select {
+		case <-fail:
+			return errors.New("failed")
+		case <-ctx.Done():
+			return nil
+		}
+	})
+	s.Add("c", block)
+
+	done := make(chan error)
+	go func() { done <- s.Run(ctx) }()
+
+	fail <- struct{}{}
+	assert.Eventually(t, func() bool {
+		return len(log.list(ChildStarted)) == 6
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, []string{"c", "a"}, log.list(ChildStopped), "Expected siblings to stop in reverse order")
+	assert.Equal(t, []string{"a", "b", "c", "a", "b", "c"}, log.list(ChildStarted))
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestSupervisor_Intensity(t *testing.T) {
+	var log eventLog
+	s := NewSupervisor(
+		WithRestartIntensity(2, time.Minute),
+		WithBackoff(0, 0),
+		WithEventHandler(log.add),
+	)
+
+	errFailed := errors.New("failed")
+	s.Add("broken", func(ctx context.Context) error { return errFailed })
+
+	err := s.Run(context.Background())
+
+	assert.ErrorIs(t, err, ErrTooManyRestarts)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Len(t, log.list(ChildStarted), 3)
+	assert.Equal(t, []string{"broken"}, log.list(SupervisorGaveUp))
+}
+
+func TestSupervisor_Policies(t *testing.T) {
+	s := NewSupervisor(WithBackoff(0, 0))
+
+	var mtx sync.Mutex
+	runs := map[string]int{}
+	count := func(name string) {
+		mtx.Lock()
+		runs[name]++
+		mtx.Unlock()
+	}
+
+	s.Add("temporary", func(ctx context.Context) error {
+		count("temporary")
+		return errors.New("failed")
+	}, WithRestartPolicy(Temporary))
+
+	s.Add("transient", func(ctx context.Context) error {
+		count("transient")
+		mtx.Lock()
+		defer mtx.Unlock()
+		if runs["transient"] == 1 {
+			return errors.New("failed")
+		}
+		return nil
+	}, WithRestartPolicy(Transient))
+
+	// Run returns once no child is left running.
+	assert.NoError(t, s.Run(context.Background()))
+
+	assert.Equal(t, 1, runs["temporary"])
+	assert.Equal(t, 2, runs["transient"])
+}
+
+func TestSupervisor_Backoff(t *testing.T) {
+	s := NewSupervisor(WithBackoff(10*time.Millisecond, 25*time.Millisecond))
+	c := &child{}
+
+	delays := []time.Duration{}
+	for i := 0; i < 4; i++ {
+		c.restarts++
+		delays = append(delays, s.backoff(c))
+	}
+
+	assert.Equal(t, []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		25 * time.Millisecond,
+		25 * time.Millisecond,
+	}, delays)
+}
+
+func TestSupervisor_ContextValues(t *testing.T) {
+	type key struct{}
+
+	s := NewSupervisor()
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	var got any
+	s.Add("reader", func(ctx context.Context) error {
+		got = ctx.Value(key{})
+		return nil
+	}, WithRestartPolicy(Transient))
+
+	assert.NoError(t, s.Run(ctx))
+	assert.Equal(t, "value", got)
+}
+
+func TestSupervisor_EventBus(t *testing.T) {
+	eb := NewEventBus()
+	ch := make(EventChannel, 10)
+	eb.Subscribe("supervisor", ch)
+
+	s := NewSupervisor(WithEventBus(eb, "supervisor"))
+	s.Add("once", func(ctx context.Context) error { return nil }, WithRestartPolicy(Temporary))
+
+	assert.NoError(t, s.Run(context.Background()))
+
+	ev := (<-ch).Data.(SupervisorEvent)
+	assert.Equal(t, ChildStarted, ev.Type)
+	assert.Equal(t, "once", ev.Child)
+	assert.Equal(t, "exited", (<-ch).Data.(SupervisorEvent).Type.String())
+}
@@ -3,38 +3,174 @@ package async
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-faster/city"
 )
 
 type EventData struct {
-	Data  any
-	Topic string
+	Data     any
+	Topic    string
+	Priority Priority
 }
 
+// EventBus delivers the events published on a topic to the channels of
+// its subscribers. Priorities only order the events of subscriptions made
+// with WithPriorities: plain subscribers receive every event in publishing
+// order, whatever its priority, which is only passed on in
+// EventData.Priority.
 type EventBus interface {
 	Publish(topic string, data any) error
+	PublishPriority(topic string, data any, priority Priority) error
 	Subscribe(topic string, ch EventChannel) uint64
+	SubscribeOnce(topic string, ch EventChannel) uint64
+	SubscribeWith(topic string, ch EventChannel, opts ...SubscribeOption) uint64
 	Unsubscribe(topic string, subscriptionID uint64)
+	Stats() BusStats
 }
 
 // EventChannel is a channel which can accept a DataEvent
 type EventChannel chan EventData
 
+// subscriber delivers events to its channel. Plain subscriptions send to
+// the channel directly. Subscriptions with priorities queue the events per
+// priority and a goroutine forwards them to the channel, higher priorities
+// first, until done is closed. Limited subscriptions own their channel and
+// close it once they are removed.
 type subscriber struct {
 	subscriptionID uint64
 	ch             EventChannel
+	dropped        atomic.Uint64
+
+	// The priority queue, set with priorities only. slots bounds the events
+	// queued in all lanes together.
+	queue lanes[EventData]
+	slots chan struct{}
+	done  chan struct{}
+
+	owned         bool
+	maxDeliveries int
+	mtx           sync.Mutex // Guards delivered when sent directly.
+	delivered     int
+	expire        func()
+	timer         *time.Timer
+}
+
+func newSubscriber(subscriptionID uint64, ch EventChannel, priorities bool) *subscriber {
+	sb := &subscriber{
+		subscriptionID: subscriptionID,
+		ch:             ch,
+	}
+	if priorities {
+		size := max(cap(ch), 1)
+		sb.queue = newLanes[EventData](size)
+		sb.slots = make(chan struct{}, size)
+		sb.done = make(chan struct{})
+	}
+	return sb
+}
+
+// deliver passes the event on without blocking. It returns false if the
+// event had to be dropped.
+func (sb *subscriber) deliver(ev EventData) bool {
+	if sb.slots != nil {
+		select {
+		case sb.slots <- struct{}{}:
+			sb.queue[ev.Priority.lane()] <- ev
+			return true
+		default:
+			return false
+		}
+	}
+
+	if sb.maxDeliveries == 0 {
+		return sb.send(ev)
+	}
+
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+	if sb.delivered >= sb.maxDeliveries {
+		// The subscription is being removed.
+		return true
+	}
+	if !sb.send(ev) {
+		return false
+	}
+	sb.delivered++
+	if sb.delivered == sb.maxDeliveries {
+		go sb.expire()
+	}
+	return true
+}
+
+func (sb *subscriber) send(ev EventData) bool {
+	select {
+	case sb.ch <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+// forward moves queued events to the subscriber channel, higher priorities
+// first. Priorities only reorder the events still queued: the ones already
+// in the channel buffer are received in the order they were forwarded.
+func (sb *subscriber) forward() {
+	if sb.owned {
+		defer close(sb.ch)
+	}
+
+	queue := sb.queue
+	for {
+		ev, res := receiveByPriority(&queue, sb.done)
+		if res != laneValue {
+			return
+		}
+		<-sb.slots
+
+		select {
+		case sb.ch <- ev:
+		case <-sb.done:
+			return
+		}
+
+		sb.delivered++
+		if sb.delivered == sb.maxDeliveries {
+			go sb.expire()
+			return
+		}
+	}
+}
+
+// stop stops delivering events. It must be called with the bus lock held
+// while the subscriber is being removed from the bus.
+func (sb *subscriber) stop() {
+	if sb.timer != nil {
+		sb.timer.Stop()
+	}
+	if sb.done != nil {
+		// The forwarding goroutine closes an owned channel.
+		close(sb.done)
+		return
+	}
+	if sb.owned {
+		close(sb.ch)
+	}
 }
 
 // eventBusImpl stores the information about subscribers interested for a particular topic
 type eventBusImpl struct {
-	subscribers map[string][]subscriber
+	subscribers map[string][]*subscriber
 	rm          sync.RWMutex
+	state       *busState
+	lastID      atomic.Uint64 // Numbers the subscriptions of the bus.
 }
 
-func NewEventBus() EventBus {
+func NewEventBus(opts ...BusOption) EventBus {
 	return &eventBusImpl{
-		subscribers: make(map[string][]subscriber),
+		subscribers: make(map[string][]*subscriber),
+		state:       newBusState(opts),
 	}
 }
 
@@ -44,20 +180,33 @@ func NewEventBus() EventBus {
 // ranges through the subscribers to send on their channels,
 // and returns any error. If no subscribers are found, it returns
 // ErrNoHandlerFound.
-func (eb *eventBusImpl) Publish(topic string, data any) (err error) {
+func (eb *eventBusImpl) Publish(topic string, data any) error {
+	return eb.PublishPriority(topic, data, PriorityNormal)
+}
+
+// PublishPriority publishes the event with the given priority. Plain
+// subscribers receive it on their channel right away, like with Publish.
+// Subscribers made with WithPriorities queue events, as many as their
+// channel holds, and receive higher priorities first. The event is dropped
+// for a subscriber whose channel or queue is full.
+func (eb *eventBusImpl) PublishPriority(topic string, data any, priority Priority) (err error) {
 	eb.rm.RLock()
 	defer eb.rm.RUnlock()
 	if sbs, found := eb.subscribers[topic]; found {
+		if len(sbs) > 0 {
+			eb.state.retain(topic, data)
+		}
 		dataEvent := EventData{
-			Data:  data,
-			Topic: topic,
+			Data:     data,
+			Topic:    topic,
+			Priority: priority,
 		}
 
 		for _, sb := range sbs {
-			select {
-			case sb.ch <- dataEvent:
-			default:
+			if !sb.deliver(dataEvent) {
 				// If the channel is full, drop the event.
+				sb.dropped.Add(1)
+				eb.state.drop(topic, sb.subscriptionID)
 				err = fmt.Errorf("event bus queue is full for topic %s", topic)
 			}
 		}
@@ -71,16 +220,43 @@ func (eb *eventBusImpl) Publish(topic string, data any) (err error) {
 // that topic, and returns the subscription ID. It locks access to the
 // subscribers map during this operation.
 func (eb *eventBusImpl) Subscribe(topic string, ch EventChannel) uint64 {
+	return eb.SubscribeWith(topic, ch)
+}
+
+// SubscribeOnce registers a subscriber for a single event. The channel is
+// closed after the event has been delivered.
+func (eb *eventBusImpl) SubscribeOnce(topic string, ch EventChannel) uint64 {
+	return eb.SubscribeWith(topic, ch, WithMaxDeliveries(1))
+}
+
+// SubscribeWith registers a subscriber configured by the options. A
+// subscriber limited to a number of deliveries, a TTL or a deadline is
+// removed from the bus once it expires. Its channel is then closed, and on
+// Unsubscribe too, so the channel must not be shared with other
+// subscriptions. The channel of an unlimited subscriber is never closed.
+// Without options it is the same as Subscribe.
+func (eb *eventBusImpl) SubscribeWith(topic string, ch EventChannel, opts ...SubscribeOption) uint64 {
+	cfg := newSubscribeConfig(opts)
+
 	eb.rm.Lock()
 	defer eb.rm.Unlock()
-	// Generate a unique subscription ID
-	subscriptionID := generateUInt64ID(topic, len(eb.subscribers[topic])+1)
-	s := subscriber{subscriptionID, ch}
+	// Generate a unique subscription ID, the number is never reused
+	subscriptionID := generateUInt64ID(topic, int(eb.lastID.Add(1)))
+	s := newSubscriber(subscriptionID, ch, cfg.priorities)
+	s.owned = cfg.limited()
+	s.maxDeliveries = cfg.maxDeliveries
+	s.expire = func() { eb.remove(topic, s) }
+	if s.owned {
+		s.timer = cfg.expireAfter(s.expire)
+	}
+	if s.done != nil {
+		go s.forward()
+	}
 
 	if prev, found := eb.subscribers[topic]; found {
 		eb.subscribers[topic] = append(prev, s)
 	} else {
-		sbs := make([]subscriber, 0, 5)
+		sbs := make([]*subscriber, 0, 5)
 		sbs = append(sbs, s)
 		eb.subscribers[topic] = sbs
 	}
@@ -97,13 +273,60 @@ func (eb *eventBusImpl) Unsubscribe(topic string, subscriptionID uint64) {
 	if sbs, found := eb.subscribers[topic]; found {
 		for i, sb := range sbs {
 			if sb.subscriptionID == subscriptionID {
-				eb.subscribers[topic] = append(sbs[:i], sbs[i+1:]...)
+				eb.removeAt(topic, i)
 				break
 			}
 		}
 	}
 }
 
+// remove unsubscribes the given subscriber if it is still subscribed.
+func (eb *eventBusImpl) remove(topic string, s *subscriber) {
+	eb.rm.Lock()
+	defer eb.rm.Unlock()
+	for i, sb := range eb.subscribers[topic] {
+		if sb == s {
+			eb.removeAt(topic, i)
+			return
+		}
+	}
+}
+
+// removeAt stops and removes the subscriber at index i of the topic. It must
+// be called with the bus lock held.
+func (eb *eventBusImpl) removeAt(topic string, i int) {
+	sbs := eb.subscribers[topic]
+	sbs[i].stop()
+	eb.subscribers[topic] = append(sbs[:i], sbs[i+1:]...)
+	if len(sbs) == 1 {
+		eb.state.forget(topic)
+	}
+}
+
+// Stats returns a snapshot of the topics, their subscribers with channel
+// usage and drop counters, the last value published to every topic when
+// the bus retains them and the most recently dropped events.
+func (eb *eventBusImpl) Stats() BusStats {
+	eb.rm.RLock()
+	defer eb.rm.RUnlock()
+
+	subs := make(map[string][]SubscriberStats, len(eb.subscribers))
+	for topic, sbs := range eb.subscribers {
+		ss := make([]SubscriberStats, 0, len(sbs))
+		for _, sb := range sbs {
+			ss = append(ss, SubscriberStats{
+				ID:       sb.subscriptionID,
+				QueueLen: len(sb.ch) + len(sb.slots),
+				QueueCap: cap(sb.ch) + cap(sb.slots),
+				Dropped:  sb.dropped.Load(),
+			})
+		}
+		subs[topic] = ss
+	}
+
+	return eb.state.snapshot(subs)
+}
+
 // generateUInt64ID generates a unique 64-bit unsigned integer ID
 // by combining the given string and integer. It hashes the
 // concatenated byte slice using the CityHash64 hashing function.
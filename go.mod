@@ -1,6 +1,6 @@
 module github.com/wormbks/dry
 
-go 1.20
+go 1.23
 
 require (
 	github.com/go-faster/city v1.0.1
@@ -3,6 +3,7 @@ package ioutils
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 )
 
 // CreateFolderStructure creates a folder structure based on the provided path.
@@ -16,3 +17,50 @@ func CreateFolderStructure(path string) error {
 	}
 	return nil
 }
+
+// WriteFileAtomic writes data to the file at path, replacing it atomically.
+// The data is written to a temporary file in the same directory, synced and
+// renamed over path, so readers see either the old or the new content.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(filepath.Clean(path))
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			//#nosec G104 -- The write failed already.
+			os.Remove(tmp)
+		}
+	}()
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing temporary file: %w", err)
+	}
+
+	if err = os.Chmod(tmp, perm); err != nil {
+		return fmt.Errorf("error setting file mode: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error replacing file: %w", err)
+	}
+
+	// Persist the rename. Not every platform can sync a directory.
+	if d, derr := os.Open(dir); derr == nil {
+		//#nosec G104 -- The file is in place already.
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package iter
+
+import "sync/atomic"
+
+// Balancer is a round-robin iterator which is safe for concurrent use
+// without locks. Next only does an atomic increment, while Add and Remove
+// replace the list of values with a modified copy. Changing the list does
+// not restart the rotation: the values keep their order and the rotation
+// goes on from the value which was due next, so no value is returned twice
+// in a row while there are others. Balancer implements Iterator[T].
+type Balancer[T comparable] struct {
+	state atomic.Pointer[balancerState[T]]
+	count atomic.Uint64
+}
+
+// balancerState is the list of values with the count at which the rotation
+// was at its first value, swapped together on updates.
+type balancerState[T any] struct {
+	values []T
+	base   uint64
+}
+
+// NewBalancer creates a new Balancer with the given values.
+func NewBalancer[T comparable](values ...T) *Balancer[T] {
+	b := &Balancer[T]{}
+	b.state.Store(&balancerState[T]{values: append([]T(nil), values...)})
+	return b
+}
+
+// Next returns the next value. It returns false if the balancer is empty.
+func (b *Balancer[T]) Next() (T, bool) {
+	s := b.state.Load()
+	if len(s.values) == 0 {
+		var zero T
+		return zero, false
+	}
+	n := b.count.Add(1) - 1
+	return s.values[(n-s.base)%uint64(len(s.values))], true
+}
+
+// Add appends value to the rotation.
+func (b *Balancer[T]) Add(value T) {
+	b.update(func(vs []T, next int) ([]T, int, bool) {
+		return append(vs, value), next, true
+	})
+}
+
+// Remove removes the first occurrence of value from the rotation. It
+// returns false if value was not found.
+func (b *Balancer[T]) Remove(value T) bool {
+	return b.update(func(vs []T, next int) ([]T, int, bool) {
+		for i, v := range vs {
+			if v != value {
+				continue
+			}
+			if i < next {
+				next--
+			}
+			vs = append(vs[:i], vs[i+1:]...)
+			if next >= len(vs) {
+				next = 0
+			}
+			return vs, next, true
+		}
+		return nil, 0, false
+	})
+}
+
+// Values returns the values in the rotation.
+func (b *Balancer[T]) Values() []T {
+	return append([]T(nil), b.state.Load().values...)
+}
+
+// Len returns the number of values in the rotation.
+func (b *Balancer[T]) Len() int {
+	return len(b.state.Load().values)
+}
+
+// update replaces the values with the result of fn applied to a copy,
+// retrying if another update won the race. fn gets the index of the value
+// due next and returns its index in the new list. Nothing is replaced if
+// fn returns false.
+func (b *Balancer[T]) update(fn func(vs []T, next int) ([]T, int, bool)) bool {
+	for {
+		old := b.state.Load()
+		count := b.count.Load()
+		next := 0
+		if len(old.values) > 0 {
+			next = int((count - old.base) % uint64(len(old.values)))
+		}
+
+		vs, next, ok := fn(append(make([]T, 0, len(old.values)+1), old.values...), next)
+		if !ok {
+			return false
+		}
+		// Rebase the rotation so that count maps to the value due next.
+		s := &balancerState[T]{values: vs, base: count - uint64(next)}
+		if b.state.CompareAndSwap(old, s) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package iter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalancer(t *testing.T) {
+	b := NewBalancer("a", "b", "c")
+	assert.Equal(t, []string{"a", "b", "c", "a"}, Collect(Take[string](b, 4)))
+
+	b.Add("d")
+	assert.Equal(t, []string{"b", "c", "d", "a"}, Collect(Take[string](b, 4)), "Expected the rotation to go on")
+
+	assert.True(t, b.Remove("b"))
+	assert.False(t, b.Remove("x"))
+	assert.Equal(t, []string{"a", "c", "d"}, b.Values())
+	assert.Equal(t, 3, b.Len())
+
+	_, ok := NewBalancer[int]().Next()
+	assert.False(t, ok, "Expected an empty balancer to be exhausted")
+}
+
+func TestBalancer_Updates(t *testing.T) {
+	b := NewBalancer("a", "b", "c")
+	assert.Equal(t, []string{"a", "b", "c", "a", "b"}, Collect(Take[string](b, 5)))
+
+	b.Add("d")
+	assert.Equal(t, []string{"c", "d", "a", "b"}, Collect(Take[string](b, 4)), "Expected the rotation to go on after b")
+
+	assert.True(t, b.Remove("c"))
+	assert.Equal(t, []string{"d", "a", "b", "d"}, Collect(Take[string](b, 4)), "Expected the removed value to be skipped")
+	assert.True(t, b.Remove("a"))
+	assert.Equal(t, []string{"b", "d"}, Collect(Take[string](b, 2)))
+	assert.True(t, b.Remove("d"))
+	assert.Equal(t, []string{"b", "b"}, Collect(Take[string](b, 2)))
+
+	// No value comes twice in a row while values come and go.
+	nums := NewBalancer(0, 1, 2, 3)
+	prev, _ := nums.Next()
+	for i := 0; i < 1000; i++ {
+		if i%3 == 0 {
+			nums.Add(4 + i)
+		}
+		if i%5 == 0 {
+			nums.Remove(nums.Values()[i%nums.Len()])
+		}
+		v, ok := nums.Next()
+		assert.True(t, ok)
+		assert.NotEqual(t, prev, v, "Expected no repeat at step %d", i)
+		prev = v
+	}
+}
+
+func TestBalancer_Parallel(t *testing.T) {
+	const workers, calls = 16, 3000
+
+	b := NewBalancer(1, 2, 3)
+
+	var mu sync.Mutex
+	counts := map[int]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local := map[int]int{}
+			for j := 0; j < calls; j++ {
+				v, ok := b.Next()
+				assert.True(t, ok)
+				local[v]++
+			}
+			mu.Lock()
+			for v, n := range local {
+				counts[v] += n
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[int]int{1: 16000, 2: 16000, 3: 16000}, counts, "Expected an even share under load")
+}
+
+func TestBalancer_ParallelUpdates(t *testing.T) {
+	b := NewBalancer(0)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(2)
+		go func(v int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				b.Add(v)
+				b.Remove(v)
+			}
+			b.Add(v)
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				_, ok := b.Next()
+				assert.True(t, ok)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8}, b.Values(), "Expected no update to be lost")
+}
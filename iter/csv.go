@@ -0,0 +1,194 @@
+package iter
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wormbks/dry/ioutils"
+)
+
+// NewCSVIterator returns an iterator decoding the rows of the CSV data of r
+// into structs of type T. The first row is the header. A column is stored in
+// the field with the same name in its csv tag, or else in the field with
+// the same name, ignoring case. A field tagged csv:"-" is skipped, as are
+// the columns without a field. The fields of the columns may be strings,
+// booleans, numbers, time.Duration or implement encoding.TextUnmarshaler;
+// empty cells leave the field at its zero value. Quoted cells may span
+// lines, and Line returns the line where a row starts. Only WithComma
+// applies, as encoding/csv always skips blank lines.
+func NewCSVIterator[T any](r io.Reader, opts ...RecordOption) *RecordIterator[T] {
+	cfg := newRecordConfig(opts)
+
+	cr := csv.NewReader(r)
+	cr.Comma = cfg.comma
+	cr.ReuseRecord = true
+
+	var (
+		fields []*csvField // The field of each column, nil if none.
+		line   int
+	)
+	readRow := func() ([]string, error) {
+		row, err := cr.Read()
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			line = perr.Line
+			return nil, perr.Err
+		}
+		if err == nil {
+			line, _ = cr.FieldPos(0)
+		}
+		return row, err
+	}
+
+	return &RecordIterator[T]{
+		read: func() (T, bool, error) {
+			var v T
+			row, err := readRow()
+			if err == nil && fields == nil {
+				if fields, err = csvFields(reflect.TypeOf(v), row); err != nil {
+					return v, false, err
+				}
+				row, err = readRow()
+			}
+			if err == io.EOF {
+				return v, false, nil
+			}
+			if err != nil {
+				return v, false, err
+			}
+
+			rv := reflect.ValueOf(&v).Elem()
+			for i, cell := range row {
+				if i >= len(fields) || fields[i] == nil || cell == "" {
+					continue
+				}
+				if err := fields[i].set(rv.FieldByIndex(fields[i].index), cell); err != nil {
+					return v, false, fmt.Errorf("column %q: %w", fields[i].name, err)
+				}
+			}
+			return v, true, nil
+		},
+		line: func() int { return line },
+	}
+}
+
+// OpenCSVIterator is like NewCSVIterator for the file at path, which may be
+// compressed with gzip. The file must be closed with Close.
+func OpenCSVIterator[T any](path string, opts ...RecordOption) (*RecordIterator[T], error) {
+	f, err := ioutils.NewGzipReader(path)
+	if err != nil {
+		return nil, err
+	}
+	it := NewCSVIterator[T](f, opts...)
+	it.closer = f
+	return it, nil
+}
+
+type csvField struct {
+	name  string
+	index []int
+	set   func(v reflect.Value, s string) error
+}
+
+// csvFields maps the columns of the header to the fields of t.
+func csvFields(t reflect.Type, header []string) ([]*csvField, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv: %v is not a struct", t)
+	}
+
+	byTag := map[string]*csvField{}
+	byName := map[string]*csvField{}
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("csv")
+		if tag == "-" {
+			continue
+		}
+		f := &csvField{name: sf.Name, index: sf.Index}
+		if tag != "" {
+			byTag[tag] = f
+		} else {
+			byName[strings.ToLower(sf.Name)] = f
+		}
+	}
+
+	fields := make([]*csvField, len(header))
+	for i, name := range header {
+		f, ok := byTag[name]
+		if !ok {
+			f, ok = byName[strings.ToLower(name)]
+		}
+		if !ok {
+			continue
+		}
+		set, err := csvSetter(t.FieldByIndex(f.index).Type)
+		if err != nil {
+			return nil, fmt.Errorf("csv: field %s: %w", f.name, err)
+		}
+		fields[i] = &csvField{name: name, index: f.index, set: set}
+	}
+	return fields, nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// csvSetter returns the function parsing a cell into a field of type t.
+func csvSetter(t reflect.Type) (func(reflect.Value, string) error, error) {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return func(v reflect.Value, s string) error {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}, nil
+	}
+	if t == durationType {
+		return func(v reflect.Value, s string) error {
+			d, err := time.ParseDuration(s)
+			v.SetInt(int64(d))
+			return err
+		}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(v reflect.Value, s string) error {
+			v.SetString(s)
+			return nil
+		}, nil
+	case reflect.Bool:
+		return func(v reflect.Value, s string) error {
+			b, err := strconv.ParseBool(s)
+			v.SetBool(b)
+			return err
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(v reflect.Value, s string) error {
+			n, err := strconv.ParseInt(s, 10, t.Bits())
+			v.SetInt(n)
+			return err
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(v reflect.Value, s string) error {
+			n, err := strconv.ParseUint(s, 10, t.Bits())
+			v.SetUint(n)
+			return err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value, s string) error {
+			f, err := strconv.ParseFloat(s, t.Bits())
+			v.SetFloat(f)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
@@ -0,0 +1,79 @@
+package iter
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type row struct {
+	Name    string
+	Count   int           `csv:"n"`
+	Ratio   float64       `csv:"ratio"`
+	Ok      bool          `csv:"ok"`
+	Timeout time.Duration `csv:"timeout"`
+	At      time.Time     `csv:"at"`
+	Skipped string        `csv:"-"`
+	Tags    []string
+}
+
+func TestCSVIterator(t *testing.T) {
+	input := "NAME;n;ratio;ok;timeout;at;skipped;extra\n" +
+		"a;1;0.5;true;1s;2024-01-01T00:00:00Z;x;y\n" +
+		"\"b\nc\";2;;false;;;;\n"
+
+	it := NewCSVIterator[row](strings.NewReader(input), WithComma(';'))
+	r, ok := it.Next()
+	assert.True(t, ok)
+	assert.Equal(t, row{
+		Name: "a", Count: 1, Ratio: 0.5, Ok: true, Timeout: time.Second,
+		At: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, r)
+	assert.Equal(t, 2, it.Line())
+
+	r, ok = it.Next()
+	assert.True(t, ok)
+	assert.Equal(t, row{Name: "b\nc", Count: 2}, r, "Expected empty cells to be zero")
+	assert.Equal(t, 3, it.Line(), "Expected the line where the row starts")
+
+	_, ok = it.Next()
+	assert.False(t, ok)
+	assert.NoError(t, it.Err())
+}
+
+func TestCSVIterator_Errors(t *testing.T) {
+	it := NewCSVIterator[row](strings.NewReader("name,n\na,1\nb,x\n"))
+	assert.Equal(t, []row{{Name: "a", Count: 1}}, Collect[row](it))
+	assert.EqualError(t, it.Err(), `line 3: column "n": strconv.ParseInt: parsing "x": invalid syntax`)
+
+	it = NewCSVIterator[row](strings.NewReader("name,n\na,1\nb\n"))
+	assert.Len(t, Collect[row](it), 1)
+	var rerr *RecordError
+	assert.True(t, errors.As(it.Err(), &rerr))
+	assert.Equal(t, 3, rerr.Line)
+	assert.ErrorIs(t, it.Err(), csv.ErrFieldCount)
+
+	it = NewCSVIterator[row](strings.NewReader("tags\nx\n"))
+	assert.Empty(t, Collect[row](it))
+	assert.Error(t, it.Err(), "Expected an error for a column of an unsupported type")
+
+	it2 := NewCSVIterator[int](strings.NewReader("a\n1\n"))
+	assert.Empty(t, Collect[int](it2))
+	assert.Error(t, it2.Err(), "Expected an error for a type which is not a struct")
+}
+
+func TestOpenCSVIterator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rows.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("name\na\nb\n"), 0o600))
+
+	it, err := OpenCSVIterator[row](path)
+	assert.NoError(t, err)
+	assert.Equal(t, []row{{Name: "a"}, {Name: "b"}}, Collect[row](it))
+	assert.NoError(t, it.Close())
+}
@@ -0,0 +1,336 @@
+package iter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAllClaimed is returned by Next in claiming mode when every
+// subdirectory is leased.
+var ErrAllClaimed = errors.New("all subdirectories are claimed")
+
+// ErrLeaseLost is returned by Release when the lease expired and was taken
+// over by another iterator.
+var ErrLeaseLost = errors.New("lease lost")
+
+// WithClaim lets several iterators, in one or more processes, share the
+// subdirectories of the root. Next leases the subdirectory it returns by
+// creating a lock file in lockDir, and skips the ones leased by others.
+// A lease lasts until Release or Close, renewed every third of ttl in the
+// background. If its holder crashes, the lease expires after ttl, one
+// minute if ttl is zero. Leases rely on the modification times of the lock
+// files, so the clocks of the hosts sharing lockDir must agree. lockDir is
+// created if needed and skipped if it is below the root. The iterator must
+// be closed with Close.
+func WithClaim(lockDir string, ttl time.Duration) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.claimDir = lockDir
+		iter.claimTTL = ttl
+	}
+}
+
+// Release gives up the lease on the subdirectory dir returned by Next in
+// claiming mode, usually once it has been processed. It returns
+// ErrLeaseLost if the lease was taken over since.
+func (iter *DirIterator) Release(dir string) error {
+	if iter.claims == nil {
+		return fmt.Errorf("%s is not claimed", dir)
+	}
+	return iter.claims.release(dir)
+}
+
+// claimNext returns the next subdirectory which could be leased. The tree
+// is walked once and the subdirectories are tried in rotation order.
+func (iter *DirIterator) claimNext() (string, error) {
+	dirs, err := iter.list()
+	if err != nil {
+		return "", err
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no subdirectories found in %s", iter.root)
+	}
+
+	start := seekIndex(dirs, iter.current)
+	for i := 1; i <= len(dirs); i++ {
+		dir := dirs[(start+i)%len(dirs)]
+		ok, err := iter.claims.acquire(dir)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			iter.Seek(dir)
+			return dir, nil
+		}
+	}
+	return "", ErrAllClaimed
+}
+
+// list returns the subdirectories, from the watcher in watch mode.
+func (iter *DirIterator) list() ([]string, error) {
+	if iter.watcher != nil {
+		return iter.watcher.list()
+	}
+	return iter.filter.subdirectories(iter.root)
+}
+
+// lease is the content of a lock file.
+type lease struct {
+	Owner string `json:"owner"`
+	Token string `json:"token"`
+}
+
+// dirClaims holds the leases of an iterator in claiming mode.
+type dirClaims struct {
+	dir   string
+	ttl   time.Duration
+	owner string
+
+	mu     sync.Mutex
+	held   map[string]string // The token of every held lease.
+	lost   map[string]bool   // The leases found taken over.
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newDirClaims(dir string, ttl time.Duration) (*dirClaims, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+
+	host, _ := os.Hostname()
+	c := &dirClaims{
+		dir:   dir,
+		ttl:   ttl,
+		owner: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		held:  map[string]string{},
+		lost:  map[string]bool{},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go c.heartbeat()
+	return c, nil
+}
+
+// path returns the lock file of the subdirectory rel.
+func (c *dirClaims) path(rel string) string {
+	return filepath.Join(c.dir, url.PathEscape(filepath.ToSlash(rel))+".lease")
+}
+
+// acquire leases rel. It returns false if rel is leased already.
+func (c *dirClaims) acquire(rel string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false, errors.New("iterator is closed")
+	}
+	if _, ok := c.held[rel]; ok {
+		return false, nil
+	}
+
+	path := c.path(rel)
+	l := lease{Owner: c.owner, Token: newToken()}
+	// A second attempt follows when an expired lease was removed.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createLease(path, l)
+		if err == nil {
+			c.held[rel] = l.Token
+			delete(c.lost, rel)
+			return true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return false, err
+		}
+		if ok, err := c.breakExpired(path); !ok || err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// breakExpired removes the lock file at path if its lease expired. It
+// returns true if there is no lock file anymore.
+func (c *dirClaims) breakExpired(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if time.Since(info.ModTime()) < c.ttl {
+		return false, nil
+	}
+	old, _ := readLease(path)
+
+	// Only one of the iterators breaking the lease at the same time can
+	// rename the lock file away.
+	expired := path + "." + newToken() + ".expired"
+	if err := os.Rename(path, expired); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	//#nosec G104 -- The lock file is moved out of the way already.
+	defer os.Remove(expired)
+
+	// The lease may have been renewed or replaced since the check.
+	info, err = os.Stat(expired)
+	cur, _ := readLease(expired)
+	if err == nil && (time.Since(info.ModTime()) < c.ttl || cur != old) {
+		//#nosec G104 -- Fails if a new lock file exists, which then wins.
+		os.Link(expired, path)
+		return false, nil
+	}
+	return true, nil
+}
+
+// release removes the lock file of rel.
+func (c *dirClaims) release(rel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, ok := c.held[rel]
+	if !ok {
+		if c.lost[rel] {
+			delete(c.lost, rel)
+			return ErrLeaseLost
+		}
+		return fmt.Errorf("%s is not claimed", rel)
+	}
+	delete(c.held, rel)
+
+	path := c.path(rel)
+	if l, err := readLease(path); err != nil || l.Token != token {
+		return ErrLeaseLost
+	}
+	return os.Remove(path)
+}
+
+// heartbeat renews the held leases until close.
+func (c *dirClaims) heartbeat() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(max(c.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.renew()
+		}
+	}
+}
+
+// renew touches the lock files of the held leases, and drops the ones
+// taken over by others.
+func (c *dirClaims) renew() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for rel, token := range c.held {
+		path := c.path(rel)
+		l, err := readLease(path)
+		if err == nil && l.Token == token {
+			err = os.Chtimes(path, now, now)
+		}
+		if err != nil || l.Token != token {
+			delete(c.held, rel)
+			c.lost[rel] = true
+		}
+	}
+}
+
+// close stops the heartbeat and releases the held leases.
+func (c *dirClaims) close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	close(c.stop)
+	<-c.done
+
+	c.mu.Lock()
+	held := make([]string, 0, len(c.held))
+	for rel := range c.held {
+		held = append(held, rel)
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, rel := range held {
+		if err := c.release(rel); err != nil && !errors.Is(err, ErrLeaseLost) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createLease creates the lock file at path, failing if it exists.
+func createLease(path string, l lease) error {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		//#nosec G104 -- The write failed already.
+		os.Remove(path)
+	}
+	return err
+}
+
+func readLease(path string) (lease, error) {
+	var l lease
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return l, err
+	}
+	err = json.Unmarshal(b, &l)
+	return l, err
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	//#nosec G104 -- crypto/rand does not fail on supported platforms.
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// skipRel returns the path of dir relative to root, if dir is below root.
+func skipRel(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return rel
+}
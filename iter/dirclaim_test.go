@@ -0,0 +1,173 @@
+package iter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newClaimIterator(t *testing.T, root, locks string, ttl time.Duration) *DirIterator {
+	iter, err := NewDirIterator(root, WithMaxDepth(1), WithClaim(locks, ttl))
+	assert.NoError(t, err)
+	t.Cleanup(func() { iter.Close() })
+	return iter
+}
+
+func TestDirIterator_Claim(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/", "b/", "c/")
+	locks := filepath.Join(root, ".locks")
+
+	first := newClaimIterator(t, root, locks, time.Minute)
+	second := newClaimIterator(t, root, locks, time.Minute)
+	assert.Equal(t, []string{"a", "b", "c"}, first.dirs, "Expected the lock directory to be skipped")
+
+	dir, err := first.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir)
+	dir, err = second.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", dir, "Expected a leased directory to be skipped")
+	dir, err = first.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "c", dir)
+
+	_, err = second.Next()
+	assert.ErrorIs(t, err, ErrAllClaimed)
+
+	assert.NoError(t, first.Release("a"))
+	dir, err = second.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir)
+	assert.Error(t, first.Release("a"), "Expected an error for a directory not claimed")
+
+	assert.NoError(t, first.Close())
+	dir, err = second.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "c", dir, "Expected Close to release the leases")
+	_, err = first.Next()
+	assert.Error(t, err, "Expected no claims after Close")
+
+	plain, err := NewDirIterator(root)
+	assert.NoError(t, err)
+	assert.Error(t, plain.Release("a"))
+}
+
+func TestDirIterator_ClaimExpired(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/")
+	locks := t.TempDir()
+
+	crashed := newClaimIterator(t, root, locks, time.Hour)
+	dir, err := crashed.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir)
+
+	// The lease looks expired, as if its holder had crashed.
+	lock := filepath.Join(locks, "a.lease")
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(lock, old, old))
+
+	other := newClaimIterator(t, root, locks, time.Hour)
+	dir, err = other.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir, "Expected an expired lease to be taken over")
+
+	assert.ErrorIs(t, crashed.Release("a"), ErrLeaseLost)
+	assert.NoError(t, other.Release("a"))
+	_, err = os.Stat(lock)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDirIterator_ClaimHeartbeat(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/")
+	locks := t.TempDir()
+
+	holder := newClaimIterator(t, root, locks, 300*time.Millisecond)
+	_, err := holder.Next()
+	assert.NoError(t, err)
+
+	other := newClaimIterator(t, root, locks, 300*time.Millisecond)
+	for i := 0; i < 6; i++ {
+		time.Sleep(100 * time.Millisecond)
+		_, err = other.Next()
+		assert.ErrorIs(t, err, ErrAllClaimed, "Expected the renewed lease to be kept")
+	}
+	assert.NoError(t, holder.Release("a"))
+}
+
+func TestDirIterator_ClaimWalk(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/", "b/", "c/")
+	locks := t.TempDir()
+
+	var visits atomic.Int32
+	count := WithDirFilter(func(string, fs.DirEntry) bool {
+		visits.Add(1)
+		return true
+	})
+	first, err := NewDirIterator(root, WithMaxDepth(1), WithClaim(locks, time.Minute), count)
+	assert.NoError(t, err)
+	t.Cleanup(func() { first.Close() })
+	second := newClaimIterator(t, root, locks, time.Minute)
+	for range 3 {
+		_, err = second.Next()
+		assert.NoError(t, err)
+	}
+
+	visits.Store(0)
+	_, err = first.Next()
+	assert.ErrorIs(t, err, ErrAllClaimed)
+	assert.Equal(t, int32(3), visits.Load(), "Expected a single walk of the tree")
+
+	// A tiny ttl must not stop the heartbeat from starting.
+	tiny := newClaimIterator(t, root, t.TempDir(), time.Nanosecond)
+	dir, err := tiny.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir)
+}
+
+func TestDirIterator_ClaimConcurrent(t *testing.T) {
+	root := t.TempDir()
+	var want []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		makeTree(t, root, name+"/")
+		want = append(want, name)
+	}
+	locks := t.TempDir()
+
+	var (
+		mu  sync.Mutex
+		got []string
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		iter := newClaimIterator(t, root, locks, time.Minute)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, err := iter.Next()
+				if err != nil {
+					assert.ErrorIs(t, err, ErrAllClaimed)
+					return
+				}
+				mu.Lock()
+				got = append(got, dir)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(got)
+	assert.Equal(t, want, got, "Expected every directory to be claimed once")
+}
@@ -0,0 +1,250 @@
+package iter
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirFilter decides which directories a DirIterator walks into and which
+// of them it returns.
+type dirFilter struct {
+	maxDepth       int
+	include        []string
+	exclude        []string
+	skipHidden     bool
+	skipEmpty      bool
+	followSymlinks bool
+	accept         func(rel string, d fs.DirEntry) bool
+	skip           string // The lock directory of WithClaim below the root.
+}
+
+// WithMaxDepth limits the iterator to n levels of subdirectories, 1 meaning
+// only the direct children of the root. Zero means no limit.
+func WithMaxDepth(n int) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.maxDepth = n
+	}
+}
+
+// WithInclude returns only the directories matching one of the patterns.
+// The iterator still walks into the other directories to find matches
+// further down. Patterns use the syntax of filepath.Match. A pattern with
+// a slash is matched against the path relative to the root, using slashes,
+// and other patterns against the directory name.
+func WithInclude(patterns ...string) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.include = append(iter.filter.include, patterns...)
+	}
+}
+
+// WithExclude skips the directories matching one of the patterns, together
+// with everything below them. Patterns are matched as in WithInclude.
+func WithExclude(patterns ...string) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.exclude = append(iter.filter.exclude, patterns...)
+	}
+}
+
+// SkipHidden skips the directories whose name starts with a dot, together
+// with everything below them.
+func SkipHidden() DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.skipHidden = true
+	}
+}
+
+// SkipEmpty does not return directories without any entries.
+func SkipEmpty() DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.skipEmpty = true
+	}
+}
+
+// FollowSymlinks walks into symbolic links to directories. A link to one
+// of its own parent directories is skipped to avoid loops.
+func FollowSymlinks() DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.followSymlinks = true
+	}
+}
+
+// WithDirFilter skips the directories for which fn returns false, together
+// with everything below them. The path is relative to the root and d is
+// the entry of the directory, or of the link to it.
+func WithDirFilter(fn func(rel string, d fs.DirEntry) bool) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.filter.accept = fn
+	}
+}
+
+// validate checks the patterns.
+func (f *dirFilter) validate() error {
+	for _, patterns := range [][]string{f.include, f.exclude} {
+		for _, p := range patterns {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// subdirectories returns the sorted list of subdirectories of root.
+func (f *dirFilter) subdirectories(root string) ([]string, error) {
+	var dirs []string
+
+	ancestors, err := f.ancestors(root, "")
+	if err != nil {
+		return nil, err
+	}
+	err = f.walk(root, "", ancestors, nil, func(rel string) {
+		dirs = append(dirs, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort the directories for consistent ordering
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
+
+// walk calls enter for rel and every accepted directory below it, before
+// reading the directory, and list for the ones the iterator returns.
+// Ancestors holds the file info of rel and its parents when symbolic links
+// are followed.
+func (f *dirFilter) walk(root, rel string, ancestors []fs.FileInfo, enter func(string) error, list func(string)) error {
+	if enter != nil {
+		if err := enter(rel); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, rel))
+	if err != nil {
+		return err
+	}
+	if rel != "" && f.listed(rel, entries) {
+		list(rel)
+	}
+
+	if f.maxDepth > 0 && depth(rel) >= f.maxDepth {
+		return nil
+	}
+
+	for _, e := range entries {
+		child := filepath.Join(rel, e.Name())
+		info, ok := f.enters(root, child, e, ancestors)
+		if !ok {
+			continue
+		}
+		err := f.walk(root, child, append(ancestors, info), enter, list)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// enters reports whether the walk goes into the entry rel. The returned
+// file info is only set when symbolic links are followed.
+func (f *dirFilter) enters(root, rel string, e fs.DirEntry, ancestors []fs.FileInfo) (fs.FileInfo, bool) {
+	if f.skipHidden && strings.HasPrefix(e.Name(), ".") {
+		return nil, false
+	}
+
+	var info fs.FileInfo
+	switch {
+	case e.IsDir():
+		if f.followSymlinks {
+			var err error
+			if info, err = e.Info(); err != nil {
+				return nil, false
+			}
+		}
+	case e.Type()&fs.ModeSymlink != 0 && f.followSymlinks:
+		var err error
+		info, err = os.Stat(filepath.Join(root, rel))
+		if err != nil || !info.IsDir() {
+			return nil, false
+		}
+		for _, a := range ancestors {
+			if os.SameFile(a, info) {
+				// The link points back up the tree.
+				return nil, false
+			}
+		}
+	default:
+		return nil, false
+	}
+
+	if matchAny(f.exclude, rel) || rel == f.skip {
+		return nil, false
+	}
+	if f.accept != nil && !f.accept(rel, e) {
+		return nil, false
+	}
+	return info, true
+}
+
+// listed reports whether the iterator returns the directory rel.
+func (f *dirFilter) listed(rel string, entries []fs.DirEntry) bool {
+	if f.skipEmpty && len(entries) == 0 {
+		return false
+	}
+	return len(f.include) == 0 || matchAny(f.include, rel)
+}
+
+// ancestors returns the file info of the root and of every directory down
+// to rel, if symbolic links are followed.
+func (f *dirFilter) ancestors(root, rel string) ([]fs.FileInfo, error) {
+	if !f.followSymlinks {
+		return nil, nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	infos := []fs.FileInfo{info}
+
+	path := root
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		if name == "" || name == "." {
+			continue
+		}
+		path = filepath.Join(path, name)
+		if info, err = os.Stat(path); err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// matchAny reports whether rel matches one of the patterns.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		name := filepath.Base(rel)
+		if strings.Contains(p, "/") {
+			name = filepath.ToSlash(rel)
+		}
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// depth returns the number of levels of rel below the root.
+func depth(rel string) int {
+	if rel == "" {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
@@ -0,0 +1,107 @@
+package iter
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeTree creates files and directories below root. Directories end with a slash.
+func makeTree(t *testing.T, root string, paths ...string) {
+	for _, p := range paths {
+		path := filepath.Join(root, filepath.FromSlash(p))
+		if p[len(p)-1] == '/' {
+			assert.NoError(t, os.MkdirAll(path, 0o755))
+			continue
+		}
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, nil, 0o600))
+	}
+}
+
+func listDirs(t *testing.T, root string, opts ...DirIteratorOption) []string {
+	iter, err := NewDirIterator(root, opts...)
+	assert.NoError(t, err)
+	if err != nil {
+		return nil
+	}
+
+	dirs := make([]string, len(iter.dirs))
+	for i, dir := range iter.dirs {
+		dirs[i] = filepath.ToSlash(dir)
+	}
+	return dirs
+}
+
+func TestDirIterator_Filters(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root,
+		"a/b/c/", "a/file", "empty/", ".hidden/x/", "logs/old/", "spool/in/", "spool/out/file")
+
+	assert.Equal(t, []string{".hidden", ".hidden/x", "a", "a/b", "a/b/c", "empty", "logs", "logs/old", "spool", "spool/in", "spool/out"},
+		listDirs(t, root))
+
+	assert.Equal(t, []string{".hidden", "a", "empty", "logs", "spool"}, listDirs(t, root, WithMaxDepth(1)))
+	assert.Equal(t, []string{"a", "a/b", "empty", "logs", "logs/old", "spool", "spool/in", "spool/out"},
+		listDirs(t, root, SkipHidden(), WithMaxDepth(2)))
+	assert.Equal(t, []string{".hidden", "a", "a/b", "logs", "spool", "spool/out"}, listDirs(t, root, SkipEmpty()))
+
+	assert.Equal(t, []string{"spool/in", "spool/out"}, listDirs(t, root, WithInclude("spool/*")))
+	assert.Equal(t, []string{"a/b", "spool/in"}, listDirs(t, root, WithInclude("b", "in")))
+	assert.Equal(t, []string{"a", "a/b", "a/b/c", "empty", "spool", "spool/out"},
+		listDirs(t, root, WithExclude(".*", "logs", "spool/in")))
+
+	assert.Equal(t, []string{"a", "empty", "logs", "spool"}, listDirs(t, root, WithDirFilter(func(rel string, d fs.DirEntry) bool {
+		return d.IsDir() && filepath.Dir(rel) == "." && rel != ".hidden"
+	})))
+
+	_, err := NewDirIterator(root, WithExclude("["))
+	assert.Error(t, err, "Expected an error for an invalid pattern")
+}
+
+func TestDirIterator_FollowSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+
+	root := t.TempDir()
+	other := t.TempDir()
+	makeTree(t, root, "a/b/")
+	makeTree(t, other, "x/")
+	assert.NoError(t, os.Symlink(other, filepath.Join(root, "link")))
+	assert.NoError(t, os.Symlink(root, filepath.Join(root, "a", "b", "loop")))
+
+	assert.Equal(t, []string{"a", "a/b"}, listDirs(t, root))
+	assert.Equal(t, []string{"a", "a/b", "link", "link/x"}, listDirs(t, root, FollowSymlinks()),
+		"Expected links to be followed and the loop to be skipped")
+}
+
+func TestDirIterator_WatchFilters(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("inotify is only available on Linux")
+	}
+
+	root := t.TempDir()
+	makeTree(t, root, "a/")
+
+	iter, err := NewDirIterator(root, WithWatch(0), SkipHidden(), SkipEmpty(), WithMaxDepth(2))
+	assert.NoError(t, err)
+	defer iter.Close()
+	assert.Empty(t, watchedDirs(iter))
+
+	makeTree(t, root, "a/file", ".git/objects/", "b/c/d/")
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"a", "b", filepath.Join("b", "c")}, watchedDirs(iter))
+	}, time.Second, time.Millisecond, "Expected the filters to apply to new directories")
+
+	assert.NoError(t, os.Remove(filepath.Join(root, "a", "file")))
+	assert.NoError(t, os.Remove(filepath.Join(root, "b", "c", "d")))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"b"}, watchedDirs(iter))
+	}, time.Second, time.Millisecond, "Expected directories to follow their emptiness")
+}
@@ -1,47 +1,112 @@
 package iter
 
 import (
+	"errors"
 	"fmt"
 	"hash/fnv"
-	"os"
 	"path/filepath"
-	"sort"
 	"strings"
+	"time"
 )
 
 // DirIterator represents a directory iterator.
 type DirIterator struct {
-	root      string   // The root directory of the iterator.
-	dirs      []string // The list of directories to iterate over.
-	current   string   // The current directory in the iteration.
-	index     int      // The current index of the iterator.
-	indexHash uint64   // The hash value of the current index.
+	root      string        // The root directory of the iterator.
+	dirs      []string      // The list of directories to iterate over.
+	current   string        // The current directory in the iteration.
+	index     int           // The current index of the iterator.
+	indexHash uint64        // The hash value of the current index.
+	err       error         // The error which stopped Iter.
+	watch     bool          // Whether to keep the list updated in the background.
+	rescan    time.Duration // The interval of the full rescan in watch mode.
+	watcher   *dirWatch     // The background watcher in watch mode.
+	filter    dirFilter     // The directories to walk into and return.
+	seeking   bool          // Whether to position after current on the next call.
+	claimDir  string        // The directory of the lock files in claiming mode.
+	claimTTL  time.Duration // The lifetime of a lease without renewal.
+	claims    *dirClaims    // The leases held in claiming mode.
+}
+
+// DirIteratorOption configures a DirIterator.
+type DirIteratorOption func(*DirIterator)
+
+// WithWatch keeps the list of subdirectories updated in the background
+// instead of walking the tree on every call to Next, which makes Next O(1).
+// On Linux the list follows inotify events, and rescan is the interval of
+// a full rescan catching anything the events missed, zero disables it.
+// Where inotify is not available only the periodic rescan is done, every
+// 10 seconds if rescan is zero. The iterator must be closed with Close.
+func WithWatch(rescan time.Duration) DirIteratorOption {
+	return func(iter *DirIterator) {
+		iter.watch = true
+		iter.rescan = rescan
+	}
 }
 
 // NewDirIterator creates a new directory iterator with the specified root directory.
-func NewDirIterator(root string) (*DirIterator, error) {
+func NewDirIterator(root string, opts ...DirIteratorOption) (*DirIterator, error) {
 	root, err := filepath.Abs(root)
 	if err != nil {
 		return nil, err
 	}
 
-	dirs, err := getSubdirectories(root)
+	iter := &DirIterator{
+		root:    root,
+		current: "",
+		index:   -1,
+	}
+	for _, opt := range opts {
+		opt(iter)
+	}
+	if err := iter.filter.validate(); err != nil {
+		return nil, err
+	}
+	if iter.claimDir != "" {
+		if iter.claimDir, err = filepath.Abs(iter.claimDir); err != nil {
+			return nil, err
+		}
+		iter.filter.skip = skipRel(root, iter.claimDir)
+	}
+
+	dirs, err := iter.filter.subdirectories(root)
 	if err != nil {
 		return nil, err
 	}
+	iter.dirs = dirs
+	iter.indexHash = calculateIndexHash(dirs)
+
+	if iter.claimDir != "" {
+		if iter.claims, err = newDirClaims(iter.claimDir, iter.claimTTL); err != nil {
+			return nil, err
+		}
+	}
+	if iter.watch {
+		iter.watcher = newDirWatch(root, dirs, iter.filter, iter.rescan)
+	}
 
-	return &DirIterator{
-		root:      root,
-		dirs:      dirs,
-		current:   "",
-		index:     -1,
-		indexHash: calculateIndexHash(dirs),
-	}, nil
+	return iter, nil
 }
 
-// Next returns the next subdirectory.
+// Next returns the next subdirectory. In claiming mode it is the next one
+// which could be leased, and ErrAllClaimed is returned if there is none.
 func (iter *DirIterator) Next() (string, error) {
-	dirs, err := getSubdirectories(iter.root)
+	if iter.claims != nil {
+		return iter.claimNext()
+	}
+	return iter.next()
+}
+
+func (iter *DirIterator) next() (string, error) {
+	if iter.watcher != nil {
+		dir, err := iter.watcher.next()
+		if err != nil {
+			return "", err
+		}
+		iter.current = dir
+		return dir, nil
+	}
+
+	dirs, err := iter.filter.subdirectories(iter.root)
 	if err != nil {
 		return "", err
 	}
@@ -51,10 +116,13 @@ func (iter *DirIterator) Next() (string, error) {
 	}
 
 	// Check if the list of subdirectories has changed
-	if iter.indexHash != calculateIndexHash(dirs) {
-		// Update the list of subdirectories
+	if hash := calculateIndexHash(dirs); iter.seeking || iter.indexHash != hash {
+		// Update the list of subdirectories and continue after the
+		// current one, wherever it moved to
 		iter.dirs = dirs
-		iter.indexHash = calculateIndexHash(dirs)
+		iter.indexHash = hash
+		iter.index = seekIndex(dirs, iter.current)
+		iter.seeking = false
 	}
 
 	// Move to the next index, loop back if necessary
@@ -66,31 +134,39 @@ func (iter *DirIterator) Next() (string, error) {
 	return iter.current, nil
 }
 
-// getSubdirectories returns a list of subdirectories.
-func getSubdirectories(root string) ([]string, error) {
-	var dirs []string
+// Close stops watching the directory tree and releases the leases held in
+// claiming mode. It does nothing if the iterator was created without
+// WithWatch and WithClaim.
+func (iter *DirIterator) Close() error {
+	var err error
+	if iter.claims != nil {
+		err = iter.claims.close()
+	}
+	if iter.watcher != nil {
+		err = errors.Join(err, iter.watcher.close())
+	}
+	return err
+}
 
-	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
+// Iter returns an endless Iterator over the subdirectories. It stops at the
+// first error returned by Next, which is then reported by Err.
+func (iter *DirIterator) Iter() Iterator[string] {
+	return IteratorFunc[string](func() (string, bool) {
+		if iter.err != nil {
+			return "", false
 		}
-		if d.IsDir() && path != root {
-			relPath, err := filepath.Rel(root, path)
-			if err != nil {
-				return err
-			}
-			dirs = append(dirs, relPath)
+		dir, err := iter.Next()
+		if err != nil {
+			iter.err = err
+			return "", false
 		}
-		return nil
+		return dir, true
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Sort the directories for consistent ordering
-	sort.Strings(dirs)
+}
 
-	return dirs, nil
+// Err returns the error which stopped the Iterator returned by Iter.
+func (iter *DirIterator) Err() error {
+	return iter.err
 }
 
 // calculateIndexHash calculates the FNV-1a hash for the list of subdirectories.
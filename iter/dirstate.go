@@ -0,0 +1,76 @@
+package iter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/wormbks/dry/ioutils"
+)
+
+// dirState is the content of a DirIterator state file.
+type dirState struct {
+	Last string `json:"last"`
+}
+
+// Position returns the subdirectory returned last, or the one given to
+// Seek since.
+func (iter *DirIterator) Position() string {
+	return iter.current
+}
+
+// Seek positions the iterator after the subdirectory name, so Next returns
+// the subdirectory which follows it in sorted order. The name does not need
+// to exist anymore. An empty name starts over from the first subdirectory.
+func (iter *DirIterator) Seek(name string) {
+	iter.current = name
+	if iter.watcher != nil {
+		iter.watcher.seek(name)
+		return
+	}
+	iter.seeking = true
+}
+
+// SaveState writes the position of the iterator to the state file at path,
+// replacing the file atomically.
+func (iter *DirIterator) SaveState(path string) error {
+	b, err := json.Marshal(dirState{Last: iter.current})
+	if err != nil {
+		return err
+	}
+	return ioutils.WriteFileAtomic(path, b, 0o600)
+}
+
+// LoadState restores the position saved by SaveState. A missing state file
+// is not an error, the iterator then starts from the first subdirectory.
+func (iter *DirIterator) LoadState(path string) error {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state dirState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	iter.Seek(state.Last)
+	return nil
+}
+
+// seekIndex returns the index after which name comes in the sorted dirs.
+func seekIndex(dirs []string, name string) int {
+	if name == "" {
+		return -1
+	}
+	i := sort.SearchStrings(dirs, name)
+	if i < len(dirs) && dirs[i] == name {
+		return i
+	}
+	return i - 1
+}
@@ -0,0 +1,76 @@
+package iter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirIterator_State(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/", "b/", "c/", "d/")
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	iter, err := NewDirIterator(root)
+	assert.NoError(t, err)
+	assert.NoError(t, iter.LoadState(state), "Expected a missing state file to be ignored")
+
+	iter.Next()
+	dir, _ := iter.Next()
+	assert.Equal(t, "b", dir)
+	assert.Equal(t, "b", iter.Position())
+	assert.NoError(t, iter.SaveState(state))
+
+	// The saved directory is gone after the restart.
+	assert.NoError(t, os.Remove(filepath.Join(root, "b")))
+
+	restored, err := NewDirIterator(root)
+	assert.NoError(t, err)
+	assert.NoError(t, restored.LoadState(state))
+	assert.Equal(t, "b", restored.Position())
+
+	dir, _ = restored.Next()
+	assert.Equal(t, "c", dir, "Expected to continue after the saved directory")
+
+	entries, err := os.ReadDir(filepath.Dir(state))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "Expected no temporary files to be left behind")
+
+	assert.NoError(t, os.WriteFile(state, []byte("{"), 0o600))
+	assert.Error(t, restored.LoadState(state))
+}
+
+func TestDirIterator_Seek(t *testing.T) {
+	root := t.TempDir()
+	makeTree(t, root, "a/", "c/", "e/")
+
+	iter, err := NewDirIterator(root)
+	assert.NoError(t, err)
+
+	iter.Seek("e")
+	dir, _ := iter.Next()
+	assert.Equal(t, "a", dir, "Expected to wrap around after the last directory")
+
+	iter.Seek("b")
+	dir, _ = iter.Next()
+	assert.Equal(t, "c", dir)
+
+	// New directories do not shift the position.
+	makeTree(t, root, "0/", "1/")
+	dir, _ = iter.Next()
+	assert.Equal(t, "e", dir)
+
+	iter.Seek("")
+	dir, _ = iter.Next()
+	assert.Equal(t, "0", dir)
+
+	watched, err := NewDirIterator(root, WithWatch(0))
+	assert.NoError(t, err)
+	defer watched.Close()
+
+	watched.Seek("c")
+	dir, _ = watched.Next()
+	assert.Equal(t, "e", dir)
+}
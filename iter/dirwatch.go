@@ -0,0 +1,205 @@
+package iter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultRescan is the rescan interval used when inotify is not available
+// and WithWatch was given no interval.
+const defaultRescan = 10 * time.Second
+
+// dirWatch keeps a sorted list of subdirectories up to date in the
+// background, from filesystem events and periodic rescans.
+type dirWatch struct {
+	root   string
+	filter dirFilter
+
+	mu    sync.Mutex
+	dirs  []string
+	index int
+	last  string // The directory returned last, or the one given to seek.
+	err   error
+
+	notifier io.Closer
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+func newDirWatch(root string, dirs []string, filter dirFilter, rescan time.Duration) *dirWatch {
+	w := &dirWatch{
+		root:   root,
+		filter: filter,
+		dirs:   dirs,
+		index:  -1,
+		stop:   make(chan struct{}),
+	}
+
+	n, err := startNotifier(w)
+	if err == nil {
+		w.notifier = n
+	} else if rescan <= 0 {
+		rescan = defaultRescan
+	}
+
+	if rescan > 0 {
+		w.wg.Add(1)
+		go w.rescanEvery(rescan)
+	}
+
+	return w
+}
+
+// next returns the next subdirectory in O(1).
+func (w *dirWatch) next() (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return "", w.err
+	}
+	if len(w.dirs) == 0 {
+		return "", fmt.Errorf("no subdirectories found in %s", w.root)
+	}
+
+	w.index = (w.index + 1) % len(w.dirs)
+	w.last = w.dirs[w.index]
+	return w.last, nil
+}
+
+// list returns a copy of the subdirectories.
+func (w *dirWatch) list() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return nil, w.err
+	}
+	return append([]string(nil), w.dirs...), nil
+}
+
+// seek positions the watch after name.
+func (w *dirWatch) seek(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.index = seekIndex(w.dirs, name)
+	w.last = name
+}
+
+func (w *dirWatch) rescanEvery(d time.Duration) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.rescan()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// rescan replaces the list with a full walk of the tree.
+func (w *dirWatch) rescan() {
+	dirs, err := w.filter.subdirectories(w.root)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+	if err == nil {
+		// Continue after the directory returned last, wherever it moved to.
+		w.dirs = dirs
+		w.index = seekIndex(dirs, w.last)
+	}
+}
+
+// add inserts rel into the list if it is not there yet.
+func (w *dirWatch) add(rel string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := sort.SearchStrings(w.dirs, rel)
+	if i < len(w.dirs) && w.dirs[i] == rel {
+		return
+	}
+	w.dirs = append(w.dirs, "")
+	copy(w.dirs[i+1:], w.dirs[i:])
+	w.dirs[i] = rel
+	if i <= w.index {
+		// Keep pointing at the directory returned last.
+		w.index++
+	}
+}
+
+// remove deletes rel and everything below it from the list.
+func (w *dirWatch) remove(rel string) {
+	prefix := rel + string(filepath.Separator)
+	w.removeIf(func(dir string) bool {
+		return dir == rel || strings.HasPrefix(dir, prefix)
+	})
+}
+
+// refresh adds or removes rel alone, depending on whether the iterator
+// returns it. Only the emptiness of a directory changes without events
+// for the directory itself.
+func (w *dirWatch) refresh(rel string) {
+	if rel == "" || !w.filter.skipEmpty {
+		return
+	}
+
+	entries, err := os.ReadDir(filepath.Join(w.root, rel))
+	switch {
+	case err != nil:
+		return
+	case w.filter.listed(rel, entries):
+		w.add(rel)
+	default:
+		w.removeIf(func(dir string) bool { return dir == rel })
+	}
+}
+
+func (w *dirWatch) removeIf(drop func(string) bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	kept := w.dirs[:0]
+	for i, dir := range w.dirs {
+		if drop(dir) {
+			if i <= w.index {
+				w.index--
+			}
+			continue
+		}
+		kept = append(kept, dir)
+	}
+	w.dirs = kept
+}
+
+// fail makes next return err until the next successful rescan.
+func (w *dirWatch) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.err = err
+}
+
+func (w *dirWatch) close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.stop)
+		if w.notifier != nil {
+			err = w.notifier.Close()
+		}
+		w.wg.Wait()
+	})
+	return err
+}
@@ -0,0 +1,187 @@
+//go:build linux
+
+package iter
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// startNotifier is replaced in tests to exercise the rescan fallback.
+var startNotifier = startInotify
+
+// inotify feeds directory events to a dirWatch. It needs a watch on every
+// directory of the tree, as inotify is not recursive.
+type inotify struct {
+	w     *dirWatch
+	file  *os.File
+	fd    int
+	wds   map[int32]string
+	paths map[string]int32
+}
+
+func startInotify(w *dirWatch) (io.Closer, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	n := &inotify{
+		w: w,
+		// A non-blocking descriptor uses the runtime poller, so closing
+		// the file unblocks a pending Read.
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		fd:    fd,
+		wds:   make(map[int32]string),
+		paths: make(map[string]int32),
+	}
+
+	ancestors, err := w.filter.ancestors(w.root, "")
+	if err == nil {
+		err = n.watchTree("", ancestors)
+	}
+	if err != nil {
+		n.file.Close()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go n.run()
+
+	return n.file, nil
+}
+
+// watchTree watches rel and every directory below it which the filter
+// walks into. Directories created before their parent was watched are added
+// to the list on the way.
+func (n *inotify) watchTree(rel string, ancestors []fs.FileInfo) error {
+	return n.w.filter.walk(n.w.root, rel, ancestors, n.watch, n.w.add)
+}
+
+func (n *inotify) watch(rel string) error {
+	wd, err := syscall.InotifyAddWatch(n.fd, filepath.Join(n.w.root, rel), inotifyMask)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	n.wds[int32(wd)] = rel
+	n.paths[rel] = int32(wd)
+	return nil
+}
+
+// unwatch drops the watches of rel and every directory below it.
+func (n *inotify) unwatch(rel string) {
+	prefix := rel + string(filepath.Separator)
+	for path, wd := range n.paths {
+		if path == rel || strings.HasPrefix(path, prefix) {
+			// Fails for deleted directories, which lost their watch already.
+			syscall.InotifyRmWatch(n.fd, uint32(wd)) // #nosec G104
+			delete(n.paths, path)
+			delete(n.wds, wd)
+		}
+	}
+}
+
+func (n *inotify) run() {
+	defer n.w.wg.Done()
+
+	buf := make([]byte, 64*1024)
+	for {
+		size, err := n.file.Read(buf)
+		if err != nil {
+			// The file was closed.
+			return
+		}
+
+		for off := 0; off+syscall.SizeofInotifyEvent <= size; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+
+			n.handle(ev.Wd, ev.Mask, string(bytes.TrimRight(name, "\x00")))
+		}
+	}
+}
+
+func (n *inotify) handle(wd int32, mask uint32, name string) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		n.resync()
+		return
+	}
+
+	parent, ok := n.wds[wd]
+	if !ok {
+		return
+	}
+
+	switch {
+	case mask&syscall.IN_IGNORED != 0:
+		delete(n.wds, wd)
+		if n.paths[parent] == wd {
+			delete(n.paths, parent)
+		}
+	case mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 && parent == "":
+		n.w.fail(&fs.PathError{Op: "watch", Path: n.w.root, Err: fs.ErrNotExist})
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		n.w.refresh(parent)
+		if err := n.created(filepath.Join(parent, name)); err != nil && !os.IsNotExist(err) {
+			n.resync()
+		}
+	case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+		rel := filepath.Join(parent, name)
+		if _, watched := n.paths[rel]; watched || mask&syscall.IN_ISDIR != 0 {
+			n.w.remove(rel)
+			n.unwatch(rel)
+		}
+		n.w.refresh(parent)
+	}
+}
+
+// created watches the new entry rel if the filter walks into it. Files
+// are only checked because they may be links to directories.
+func (n *inotify) created(rel string) error {
+	f := &n.w.filter
+	if f.maxDepth > 0 && depth(rel) > f.maxDepth {
+		return nil
+	}
+
+	info, err := os.Lstat(filepath.Join(n.w.root, rel))
+	if err != nil {
+		return err
+	}
+	ancestors, err := f.ancestors(n.w.root, filepath.Dir(rel))
+	if err != nil {
+		return err
+	}
+
+	dirInfo, ok := f.enters(n.w.root, rel, fs.FileInfoToDirEntry(info), ancestors)
+	if !ok {
+		return nil
+	}
+	return n.watchTree(rel, append(ancestors, dirInfo))
+}
+
+// resync rescans the tree after events were lost and watches the
+// directories which have no watch yet.
+func (n *inotify) resync() {
+	n.w.rescan()
+
+	n.w.mu.Lock()
+	dirs := append([]string(nil), n.w.dirs...)
+	n.w.mu.Unlock()
+
+	for _, rel := range dirs {
+		if _, ok := n.paths[rel]; !ok {
+			n.created(rel) // #nosec G104 -- The next resync retries.
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package iter
+
+import (
+	"errors"
+	"io"
+)
+
+// startNotifier is replaced in tests to exercise the rescan fallback.
+var startNotifier = func(*dirWatch) (io.Closer, error) {
+	return nil, errors.New("directory events are not supported on this platform")
+}
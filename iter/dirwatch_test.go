@@ -0,0 +1,133 @@
+package iter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func watchedDirs(iter *DirIterator) []string {
+	w := iter.watcher
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.dirs...)
+}
+
+func TestDirIterator_Watch(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("inotify is only available on Linux")
+	}
+
+	rootDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "a"), 0o755))
+
+	iter, err := NewDirIterator(rootDir, WithWatch(0))
+	assert.NoError(t, err)
+	defer iter.Close()
+
+	dir, err := iter.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "b"), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Join(rootDir, "c", "d", "e"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootDir, "file"), nil, 0o600))
+
+	want := []string{"a", "b", "c", filepath.Join("c", "d"), filepath.Join("c", "d", "e")}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(want, watchedDirs(iter))
+	}, time.Second, time.Millisecond, "Expected new directories to be picked up")
+
+	assert.NoError(t, os.RemoveAll(filepath.Join(rootDir, "c")))
+	assert.NoError(t, os.Rename(filepath.Join(rootDir, "b"), filepath.Join(rootDir, "x")))
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"a", "x"}, watchedDirs(iter))
+	}, time.Second, time.Millisecond, "Expected removed directories to be dropped")
+
+	dir, err = iter.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "x", dir, "Expected the position after the last directory to be kept")
+
+	assert.NoError(t, os.RemoveAll(rootDir))
+	assert.Eventually(t, func() bool {
+		_, err := iter.Next()
+		return err != nil
+	}, time.Second, time.Millisecond, "Expected an error once the root is removed")
+}
+
+func TestDirIterator_WatchRescan(t *testing.T) {
+	start := startNotifier
+	startNotifier = func(*dirWatch) (io.Closer, error) { return nil, errors.New("unsupported") }
+	defer func() { startNotifier = start }()
+
+	rootDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "a"), 0o755))
+
+	iter, err := NewDirIterator(rootDir, WithWatch(5*time.Millisecond))
+	assert.NoError(t, err)
+	defer iter.Close()
+
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "b"), 0o755))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"a", "b"}, watchedDirs(iter))
+	}, time.Second, time.Millisecond, "Expected the rescan to pick up new directories")
+
+	assert.NoError(t, os.RemoveAll(rootDir))
+	assert.Eventually(t, func() bool {
+		_, err := iter.Next()
+		return err != nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestDirIterator_WatchRescanPosition(t *testing.T) {
+	start := startNotifier
+	startNotifier = func(*dirWatch) (io.Closer, error) { return nil, errors.New("unsupported") }
+	defer func() { startNotifier = start }()
+
+	rootDir := t.TempDir()
+	makeTree(t, rootDir, "a/", "c/", "d/")
+
+	iter, err := NewDirIterator(rootDir, WithWatch(time.Hour))
+	assert.NoError(t, err)
+	defer iter.Close()
+
+	next := func() string {
+		dir, err := iter.Next()
+		assert.NoError(t, err)
+		return dir
+	}
+	assert.Equal(t, "a", next())
+	assert.Equal(t, "c", next())
+
+	makeTree(t, rootDir, "b/")
+	iter.watcher.rescan()
+	assert.Equal(t, "d", next(), "Expected to continue after c")
+
+	assert.NoError(t, os.Remove(filepath.Join(rootDir, "a")))
+	iter.watcher.rescan()
+	assert.Equal(t, "b", next(), "Expected no skip after a removed directory")
+	assert.Equal(t, "c", next())
+}
+
+func TestDirIterator_Close(t *testing.T) {
+	rootDir := t.TempDir()
+
+	iter, err := NewDirIterator(rootDir)
+	assert.NoError(t, err)
+	assert.NoError(t, iter.Close(), "Expected Close to do nothing without WithWatch")
+
+	iter, err = NewDirIterator(rootDir, WithWatch(time.Millisecond))
+	assert.NoError(t, err)
+	assert.NoError(t, iter.Close())
+	assert.NoError(t, iter.Close(), "Expected Close to be idempotent")
+
+	_, err = iter.Next()
+	assert.Error(t, err, "Expected an error without subdirectories")
+}
@@ -3,5 +3,8 @@ Package iter provides various types of iterators including generic array iterato
 directory iterator and integer range iterator.
 These iterators are designed to provide a simple and consistent
 interface for iterating over collections or ranges.
+They all implement or convert to the generic Iterator interface, which
+can be composed with adapters such as Map, Filter, Take and Zip, and
+their Seq and All methods allow ranging over them with a for loop.
 */
 package iter
@@ -0,0 +1,317 @@
+package iter
+
+import (
+	"container/heap"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileEntry is a regular file returned by a FileIterator.
+type FileEntry struct {
+	Path    string // The path relative to the root.
+	Size    int64
+	ModTime time.Time
+	Mode    fs.FileMode
+}
+
+// FileSort is the order of a FileIterator.
+type FileSort int
+
+const (
+	// SortByName walks the tree in lexical order of the names, like
+	// filepath.WalkDir.
+	SortByName FileSort = iota
+	// SortByModTime returns the files from the oldest to the newest.
+	SortByModTime
+	// SortBySize returns the files from the smallest to the largest.
+	SortBySize
+)
+
+// FileIteratorOption configures a FileIterator.
+type FileIteratorOption func(*FileIterator)
+
+// WithFileSort sets the order of the files. The default is SortByName.
+func WithFileSort(by FileSort) FileIteratorOption {
+	return func(it *FileIterator) {
+		it.sort = by
+	}
+}
+
+// OldestFirst returns the files from the oldest to the newest, as needed to
+// process a spool or inbox directory. It is the same as
+// WithFileSort(SortByModTime).
+func OldestFirst() FileIteratorOption {
+	return WithFileSort(SortByModTime)
+}
+
+// WithFileReverse reverses the order of the files.
+func WithFileReverse() FileIteratorOption {
+	return func(it *FileIterator) {
+		it.reverse = true
+	}
+}
+
+// WithFileInclude returns only the files matching one of the patterns.
+// Patterns are matched as in WithInclude.
+func WithFileInclude(patterns ...string) FileIteratorOption {
+	return func(it *FileIterator) {
+		it.include = append(it.include, patterns...)
+	}
+}
+
+// WithFileExclude skips the files matching one of the patterns. Patterns
+// are matched as in WithInclude.
+func WithFileExclude(patterns ...string) FileIteratorOption {
+	return func(it *FileIterator) {
+		it.exclude = append(it.exclude, patterns...)
+	}
+}
+
+// WithFileBatch sets how many files a FileIterator sorted by time or size
+// keeps in memory. The default is 1024.
+func WithFileBatch(n int) FileIteratorOption {
+	return func(it *FileIterator) {
+		it.batch = n
+	}
+}
+
+// FileIterator lazily returns the regular files below a root directory.
+// In name order it only holds the entries of the directories on the current
+// path. Sorted by time or size it walks the tree once per batch of files,
+// keeping only the batch in memory, so a batch reflects the tree when it
+// was walked. Errors stop the iteration and are reported by Err.
+// FileIterator implements Iterator[FileEntry].
+type FileIterator struct {
+	root    string
+	sort    FileSort
+	reverse bool
+	include []string
+	exclude []string
+	batch   int
+
+	walker *fileWalker // The walk in name order.
+	buf    []FileEntry // The current batch in the other orders.
+	last   *FileEntry  // The last entry of the previous batch.
+	done   bool
+	err    error
+}
+
+// NewFileIterator creates a new file iterator with the specified root
+// directory.
+func NewFileIterator(root string, opts ...FileIteratorOption) (*FileIterator, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &FileIterator{root: root, batch: 1024}
+	for _, opt := range opts {
+		opt(it)
+	}
+	if it.batch < 1 {
+		it.batch = 1
+	}
+	for _, p := range append(append([]string{}, it.include...), it.exclude...) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+
+	if _, err := os.ReadDir(root); err != nil {
+		return nil, err
+	}
+	it.Reset()
+	return it, nil
+}
+
+// Next returns the next file. It returns false when all files have been
+// returned or an error occurred.
+func (it *FileIterator) Next() (FileEntry, bool) {
+	if it.err != nil {
+		return FileEntry{}, false
+	}
+
+	if it.sort == SortByName {
+		e, ok, err := it.walker.next()
+		it.err = err
+		return e, ok
+	}
+
+	if len(it.buf) == 0 && !it.done {
+		it.fill()
+	}
+	if len(it.buf) == 0 {
+		return FileEntry{}, false
+	}
+	e := it.buf[0]
+	it.buf = it.buf[1:]
+	return e, true
+}
+
+// Err returns the error which stopped the iteration.
+func (it *FileIterator) Err() error {
+	return it.err
+}
+
+// Reset restarts the iteration from the first file.
+func (it *FileIterator) Reset() {
+	it.walker = it.newWalker()
+	it.buf = nil
+	it.last = nil
+	it.done = false
+	it.err = nil
+}
+
+func (it *FileIterator) newWalker() *fileWalker {
+	return &fileWalker{root: it.root, reverse: it.reverse && it.sort == SortByName, match: it.matches}
+}
+
+func (it *FileIterator) matches(rel string) bool {
+	if len(it.include) > 0 && !matchAny(it.include, rel) {
+		return false
+	}
+	return !matchAny(it.exclude, rel)
+}
+
+// less orders the files in the sort order, by path within the same key.
+func (it *FileIterator) less(a, b *FileEntry) bool {
+	if it.reverse {
+		a, b = b, a
+	}
+	switch it.sort {
+	case SortByModTime:
+		if !a.ModTime.Equal(b.ModTime) {
+			return a.ModTime.Before(b.ModTime)
+		}
+	case SortBySize:
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+	}
+	return a.Path < b.Path
+}
+
+// fill walks the tree for the next batch: the first files in sort order
+// after the last one of the previous batch.
+func (it *FileIterator) fill() {
+	h := &fileHeap{less: it.less}
+	w := it.newWalker()
+	for {
+		e, ok, err := w.next()
+		if err != nil {
+			it.err = err
+			return
+		}
+		if !ok {
+			break
+		}
+		if it.last != nil && !it.less(it.last, &e) {
+			continue
+		}
+		if h.Len() < it.batch {
+			heap.Push(h, e)
+		} else if it.less(&e, &h.entries[0]) {
+			h.entries[0] = e
+			heap.Fix(h, 0)
+		}
+	}
+
+	it.buf = h.entries
+	sort.Slice(it.buf, func(i, j int) bool { return it.less(&it.buf[i], &it.buf[j]) })
+	if len(it.buf) < it.batch {
+		it.done = true
+	}
+	if len(it.buf) > 0 {
+		last := it.buf[len(it.buf)-1]
+		it.last = &last
+	}
+}
+
+// fileHeap is a max-heap of the files of a batch, in sort order.
+type fileHeap struct {
+	entries []FileEntry
+	less    func(a, b *FileEntry) bool
+}
+
+func (h *fileHeap) Len() int           { return len(h.entries) }
+func (h *fileHeap) Less(i, j int) bool { return h.less(&h.entries[j], &h.entries[i]) }
+func (h *fileHeap) Swap(i, j int)      { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *fileHeap) Push(x any)         { h.entries = append(h.entries, x.(FileEntry)) }
+func (h *fileHeap) Pop() any {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return e
+}
+
+// fileWalker walks the tree depth first, holding the entries of the
+// directories on the current path.
+type fileWalker struct {
+	root    string
+	reverse bool
+	match   func(rel string) bool
+	stack   []fileFrame
+	started bool
+}
+
+type fileFrame struct {
+	rel     string
+	entries []fs.DirEntry
+}
+
+func (w *fileWalker) next() (FileEntry, bool, error) {
+	if !w.started {
+		w.started = true
+		if err := w.push(""); err != nil {
+			return FileEntry{}, false, err
+		}
+	}
+
+	for len(w.stack) > 0 {
+		top := &w.stack[len(w.stack)-1]
+		if len(top.entries) == 0 {
+			w.stack = w.stack[:len(w.stack)-1]
+			continue
+		}
+
+		var e fs.DirEntry
+		if w.reverse {
+			e = top.entries[len(top.entries)-1]
+			top.entries = top.entries[:len(top.entries)-1]
+		} else {
+			e = top.entries[0]
+			top.entries = top.entries[1:]
+		}
+		rel := filepath.Join(top.rel, e.Name())
+
+		switch {
+		case e.IsDir():
+			if err := w.push(rel); err != nil && !os.IsNotExist(err) {
+				return FileEntry{}, false, err
+			}
+		case e.Type().IsRegular() && w.match(rel):
+			info, err := e.Info()
+			if os.IsNotExist(err) {
+				// Removed since the directory was read.
+				continue
+			}
+			if err != nil {
+				return FileEntry{}, false, err
+			}
+			return FileEntry{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Mode: info.Mode()}, true, nil
+		}
+	}
+	return FileEntry{}, false, nil
+}
+
+func (w *fileWalker) push(rel string) error {
+	entries, err := os.ReadDir(filepath.Join(w.root, rel))
+	if err != nil {
+		return err
+	}
+	w.stack = append(w.stack, fileFrame{rel: rel, entries: entries})
+	return nil
+}
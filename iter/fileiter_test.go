@@ -0,0 +1,107 @@
+package iter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// makeFiles creates the files below root with the given sizes, each one a
+// minute newer than the one before.
+func makeFiles(t *testing.T, root string, files []string, sizes []int) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, make([]byte, sizes[i]), 0o600))
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, os.Chtimes(path, mtime, mtime))
+	}
+}
+
+func filePaths(t *testing.T, root string, opts ...FileIteratorOption) []string {
+	it, err := NewFileIterator(root, opts...)
+	assert.NoError(t, err)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for e, ok := it.Next(); ok; e, ok = it.Next() {
+		paths = append(paths, filepath.ToSlash(e.Path))
+	}
+	assert.NoError(t, it.Err())
+	return paths
+}
+
+func TestFileIterator(t *testing.T) {
+	root := t.TempDir()
+	makeFiles(t, root,
+		[]string{"c.log", "a/z.txt", "b.txt", "a/b/y.log", "d.txt"},
+		[]int{30, 10, 50, 20, 40})
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "empty"), 0o755))
+	// Links are skipped, where they can be created.
+	os.Symlink(filepath.Join(root, "b.txt"), filepath.Join(root, "link.txt"))
+
+	byName := []string{"a/b/y.log", "a/z.txt", "b.txt", "c.log", "d.txt"}
+	assert.Equal(t, byName, filePaths(t, root), "Expected only regular files")
+	assert.Equal(t, []string{"d.txt", "c.log", "b.txt", "a/z.txt", "a/b/y.log"}, filePaths(t, root, WithFileReverse()))
+
+	oldest := []string{"c.log", "a/z.txt", "b.txt", "a/b/y.log", "d.txt"}
+	assert.Equal(t, oldest, filePaths(t, root, OldestFirst()))
+	assert.Equal(t, oldest, filePaths(t, root, OldestFirst(), WithFileBatch(2)), "Expected batches to keep the order")
+
+	bySize := []string{"a/z.txt", "a/b/y.log", "c.log", "d.txt", "b.txt"}
+	assert.Equal(t, bySize, filePaths(t, root, WithFileSort(SortBySize), WithFileBatch(1)))
+	assert.Equal(t, []string{"b.txt", "d.txt", "c.log", "a/b/y.log", "a/z.txt"},
+		filePaths(t, root, WithFileSort(SortBySize), WithFileReverse(), WithFileBatch(3)))
+
+	assert.Equal(t, []string{"a/b/y.log", "c.log"}, filePaths(t, root, WithFileInclude("*.log")))
+	assert.Equal(t, []string{"b.txt", "d.txt"}, filePaths(t, root, WithFileInclude("*.txt"), WithFileExclude("a/*")))
+
+	_, err := NewFileIterator(root, WithFileInclude("["))
+	assert.Error(t, err, "Expected an error for an invalid pattern")
+	_, err = NewFileIterator(filepath.Join(root, "missing"))
+	assert.Error(t, err)
+}
+
+func TestFileIterator_Entry(t *testing.T) {
+	root := t.TempDir()
+	makeFiles(t, root, []string{"a", "b"}, []int{3, 3})
+
+	it, err := NewFileIterator(root, OldestFirst(), WithFileReverse())
+	assert.NoError(t, err)
+
+	e, ok := it.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "b", e.Path)
+	assert.Equal(t, int64(3), e.Size)
+	assert.True(t, e.Mode.IsRegular())
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC), e.ModTime.UTC())
+
+	it.Reset()
+	assert.Equal(t, []string{"b", "a"}, Collect(Map[FileEntry](it, func(e FileEntry) string { return e.Path })))
+}
+
+func TestFileIterator_Err(t *testing.T) {
+	root := t.TempDir()
+	makeFiles(t, root, []string{"a/1", "b/2"}, []int{1, 1})
+
+	it, err := NewFileIterator(root)
+	assert.NoError(t, err)
+
+	e, ok := it.Next()
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join("a", "1"), e.Path)
+
+	// b was read as a directory, but is a file by now.
+	assert.NoError(t, os.RemoveAll(filepath.Join(root, "b")))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "b"), nil, 0o600))
+
+	_, ok = it.Next()
+	assert.False(t, ok)
+	assert.Error(t, it.Err(), "Expected the failed read to be reported")
+}
@@ -0,0 +1,232 @@
+package iter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-faster/city"
+)
+
+// Picker selects an element by key. The same key picks the same element as
+// long as the set of elements does not change.
+type Picker[T any] interface {
+	Pick(key string) (T, bool)
+}
+
+// nameFunc returns name, or a function naming elements with fmt.Sprint.
+func nameFunc[T any](name func(T) string) func(T) string {
+	if name != nil {
+		return name
+	}
+	return func(v T) string { return fmt.Sprint(v) }
+}
+
+type ringPoint[T any] struct {
+	hash uint64
+	name string
+	elem T
+}
+
+// HashRing is a consistent hash ring. Every element is placed on the ring
+// at a number of points, its virtual nodes, and a key picks the element of
+// the first point at or after the hash of the key. Adding or removing an
+// element only remaps the keys of its own points, about 1/n of them.
+// Elements are placed by their name, so the ring is the same on every
+// process with the same elements. HashRing is safe for concurrent use and
+// implements Picker[T].
+type HashRing[T comparable] struct {
+	replicas int
+	name     func(T) string
+
+	mu     sync.RWMutex
+	points []ringPoint[T]
+	elems  map[T]struct{}
+}
+
+// NewHashRing creates an empty ring which places every element at the given
+// number of virtual nodes. More virtual nodes spread the keys more evenly,
+// a hundred or so is usual. If name is nil, elements are named with
+// fmt.Sprint.
+func NewHashRing[T comparable](replicas int, name func(T) string) *HashRing[T] {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &HashRing[T]{
+		replicas: replicas,
+		name:     nameFunc(name),
+		elems:    make(map[T]struct{}),
+	}
+}
+
+// Add adds the elements to the ring. Elements already on the ring are
+// ignored.
+func (r *HashRing[T]) Add(elems ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range elems {
+		if _, ok := r.elems[e]; ok {
+			continue
+		}
+		r.elems[e] = struct{}{}
+
+		name := r.name(e)
+		for i := 0; i < r.replicas; i++ {
+			r.points = append(r.points, ringPoint[T]{
+				hash: city.Hash64WithSeed([]byte(name), uint64(i)),
+				name: name,
+				elem: e,
+			})
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		a, b := r.points[i], r.points[j]
+		if a.hash != b.hash {
+			return a.hash < b.hash
+		}
+		// Keep colliding points in the same order everywhere.
+		return a.name < b.name
+	})
+}
+
+// Remove removes the elements from the ring.
+func (r *HashRing[T]) Remove(elems ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range elems {
+		delete(r.elems, e)
+	}
+
+	kept := r.points[:0]
+	for _, p := range r.points {
+		if _, ok := r.elems[p.elem]; ok {
+			kept = append(kept, p)
+		}
+	}
+	r.points = kept
+}
+
+// Pick returns the element for key. It returns false if the ring is empty.
+func (r *HashRing[T]) Pick(key string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.points) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	h := city.Hash64([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].elem, true
+}
+
+// Len returns the number of elements on the ring.
+func (r *HashRing[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.elems)
+}
+
+type rendezvousElem[T any] struct {
+	seed uint64
+	name string
+	elem T
+}
+
+// Rendezvous selects elements by rendezvous, or highest random weight,
+// hashing: a key picks the element with the highest hash of the key and the
+// element name. Removing an element only remaps the keys it had, and adding
+// one only takes over the keys it wins, about 1/n of them. It needs no
+// virtual nodes but Pick is O(n). Rendezvous is safe for concurrent use and
+// implements Picker[T].
+type Rendezvous[T comparable] struct {
+	name func(T) string
+
+	mu    sync.RWMutex
+	elems []rendezvousElem[T]
+}
+
+// NewRendezvous creates an empty selector. If name is nil, elements are
+// named with fmt.Sprint.
+func NewRendezvous[T comparable](name func(T) string) *Rendezvous[T] {
+	return &Rendezvous[T]{name: nameFunc(name)}
+}
+
+// Add adds the elements. Elements added before are ignored.
+func (r *Rendezvous[T]) Add(elems ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range elems {
+		if r.find(e) >= 0 {
+			continue
+		}
+		name := r.name(e)
+		r.elems = append(r.elems, rendezvousElem[T]{
+			seed: city.Hash64([]byte(name)),
+			name: name,
+			elem: e,
+		})
+	}
+}
+
+// Remove removes the elements.
+func (r *Rendezvous[T]) Remove(elems ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range elems {
+		if i := r.find(e); i >= 0 {
+			r.elems = append(r.elems[:i], r.elems[i+1:]...)
+		}
+	}
+}
+
+// Pick returns the element for key. It returns false if there are no
+// elements.
+func (r *Rendezvous[T]) Pick(key string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var (
+		best      *rendezvousElem[T]
+		bestScore uint64
+	)
+	k := []byte(key)
+	for i := range r.elems {
+		e := &r.elems[i]
+		score := city.Hash64WithSeed(k, e.seed)
+		if best == nil || score > bestScore || (score == bestScore && e.name < best.name) {
+			best, bestScore = e, score
+		}
+	}
+
+	if best == nil {
+		var zero T
+		return zero, false
+	}
+	return best.elem, true
+}
+
+// Len returns the number of elements.
+func (r *Rendezvous[T]) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.elems)
+}
+
+func (r *Rendezvous[T]) find(e T) int {
+	for i := range r.elems {
+		if r.elems[i].elem == e {
+			return i
+		}
+	}
+	return -1
+}
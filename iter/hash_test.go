@@ -0,0 +1,117 @@
+package iter
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const hashKeys = 10000
+
+// pickAll returns the element picked for every test key.
+func pickAll(t *testing.T, p Picker[string]) []string {
+	picks := make([]string, hashKeys)
+	for i := range picks {
+		v, ok := p.Pick("key-" + strconv.Itoa(i))
+		assert.True(t, ok)
+		picks[i] = v
+	}
+	return picks
+}
+
+// moved returns the share of keys picking another element.
+func moved(a, b []string) float64 {
+	n := 0
+	for i := range a {
+		if a[i] != b[i] {
+			n++
+		}
+	}
+	return float64(n) / float64(len(a))
+}
+
+// pickerSet is implemented by HashRing and Rendezvous.
+type pickerSet interface {
+	Picker[string]
+	Add(...string)
+	Remove(...string)
+	Len() int
+}
+
+func testPicker(t *testing.T, newPicker func() pickerSet) {
+	p := newPicker()
+	_, ok := p.Pick("key")
+	assert.False(t, ok, "Expected nothing to pick without elements")
+
+	p.Add("a", "b", "c", "d")
+	p.Add("a")
+	assert.Equal(t, 4, p.Len())
+
+	before := pickAll(t, p)
+	assert.Equal(t, before, pickAll(t, p), "Expected the picks to be stable")
+
+	counts := map[string]int{}
+	for _, v := range before {
+		counts[v]++
+	}
+	for v, n := range counts {
+		assert.InDelta(t, hashKeys/4, n, hashKeys/10, "Expected an even share for %s", v)
+	}
+
+	// Another picker with the same elements picks the same.
+	other := newPicker()
+	other.Add("d", "c", "b", "a")
+	assert.Equal(t, before, pickAll(t, other))
+
+	p.Add("e")
+	added := pickAll(t, p)
+	assert.InDelta(t, 0.2, moved(before, added), 0.05, "Expected about a fifth of the keys to move")
+	for i := range before {
+		if before[i] != added[i] {
+			assert.Equal(t, "e", added[i], "Expected keys to only move to the new element")
+		}
+	}
+
+	p.Remove("e")
+	assert.Equal(t, before, pickAll(t, p), "Expected the picks to be restored")
+
+	p.Remove("b")
+	removed := pickAll(t, p)
+	for i := range before {
+		if before[i] != "b" {
+			assert.Equal(t, before[i], removed[i], "Expected only the keys of the removed element to move")
+		}
+	}
+}
+
+func TestHashRing(t *testing.T) {
+	testPicker(t, func() pickerSet {
+		return NewHashRing[string](200, nil)
+	})
+}
+
+func TestRendezvous(t *testing.T) {
+	testPicker(t, func() pickerSet {
+		return NewRendezvous[string](nil)
+	})
+}
+
+func TestHashRing_Name(t *testing.T) {
+	type backend struct{ host string }
+
+	r := NewHashRing(10, func(b *backend) string { return b.host })
+	a, b := &backend{"a"}, &backend{"a"}
+	r.Add(a)
+
+	v, ok := r.Pick("key")
+	assert.True(t, ok)
+	assert.Same(t, a, v)
+
+	// Elements with the same name share their points.
+	r.Add(b)
+	assert.Equal(t, 2, r.Len())
+	r.Remove(a)
+	v, _ = r.Pick("key")
+	assert.Same(t, b, v)
+}
@@ -0,0 +1,244 @@
+package iter
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthState is the state of a value in a HealthIterator.
+type HealthState int
+
+const (
+	// Healthy values are returned in turn.
+	Healthy HealthState = iota
+	// Ejected values are skipped until their cooldown has passed.
+	Ejected
+	// HalfOpen values have served their cooldown and are returned once, as
+	// a probe, until the outcome of the probe is reported.
+	HalfOpen
+)
+
+// String implements the fmt.Stringer interface.
+func (s HealthState) String() string {
+	switch s {
+	case Healthy:
+		return "healthy"
+	case Ejected:
+		return "ejected"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// HealthOption configures a HealthIterator.
+type HealthOption func(*healthConfig)
+
+type healthConfig struct {
+	threshold   int
+	minCooldown time.Duration
+	maxCooldown time.Duration
+	failOpen    bool
+	now         func() time.Time
+}
+
+// WithFailureThreshold ejects a value after n consecutive failures. The
+// default is 3.
+func WithFailureThreshold(n int) HealthOption {
+	return func(c *healthConfig) {
+		c.threshold = n
+	}
+}
+
+// WithCooldown sets how long a value stays ejected. The first ejection lasts
+// min, and every further ejection without a success in between doubles it,
+// up to max. The defaults are 1 second and 1 minute.
+func WithCooldown(min, max time.Duration) HealthOption {
+	return func(c *healthConfig) {
+		c.minCooldown = min
+		c.maxCooldown = max
+	}
+}
+
+// WithFailOpen sets whether Next returns ejected values when every value is
+// ejected, rather than nothing. It is enabled by default.
+func WithFailOpen(enabled bool) HealthOption {
+	return func(c *healthConfig) {
+		c.failOpen = enabled
+	}
+}
+
+// WithNow sets the function returning the current time, for tests.
+func WithNow(now func() time.Time) HealthOption {
+	return func(c *healthConfig) {
+		c.now = now
+	}
+}
+
+type healthItem[T any] struct {
+	value     T
+	state     HealthState
+	failures  int       // consecutive failures
+	ejections int       // consecutive ejections
+	until     time.Time // end of the cooldown
+	probeAt   time.Time // when the pending probe was handed out
+}
+
+// HealthIterator is an endless round-robin iterator over values, such as
+// endpoints, whose callers report the outcome of using them. A value is
+// ejected after a number of consecutive failures, for a cooldown which
+// grows exponentially with every further ejection. After the cooldown the
+// value is returned once as a probe: a success makes it healthy again and
+// a failure ejects it again. HealthIterator is safe for concurrent use and
+// implements Iterator[T].
+type HealthIterator[T comparable] struct {
+	cfg   healthConfig
+	mu    sync.Mutex
+	items []healthItem[T]
+	index int
+}
+
+// NewHealthIterator creates a new HealthIterator over values, which all
+// start healthy.
+func NewHealthIterator[T comparable](values []T, opts ...HealthOption) *HealthIterator[T] {
+	cfg := healthConfig{
+		threshold:   3,
+		minCooldown: time.Second,
+		maxCooldown: time.Minute,
+		failOpen:    true,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	h := &HealthIterator[T]{cfg: cfg, index: -1}
+	for _, v := range values {
+		h.items = append(h.items, healthItem[T]{value: v})
+	}
+	return h
+}
+
+// Next returns the next value which is healthy or due for a probe. If every
+// value is ejected it returns the next one anyway with fail open, and false
+// otherwise.
+func (h *HealthIterator[T]) Next() (T, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.cfg.now()
+	n := len(h.items)
+	for k := 1; k <= n; k++ {
+		i := (h.index + k) % n
+		item := &h.items[i]
+
+		h.refresh(item, now)
+		switch item.state {
+		case Ejected:
+			continue
+		case HalfOpen:
+			// A probe which was never reported expires like a cooldown.
+			if now.Sub(item.probeAt) < h.cfg.minCooldown {
+				continue
+			}
+			item.probeAt = now
+		}
+
+		h.index = i
+		return item.value, true
+	}
+
+	if h.cfg.failOpen && n > 0 {
+		h.index = (h.index + 1) % n
+		return h.items[h.index].value, true
+	}
+
+	var zero T
+	return zero, false
+}
+
+// ReportSuccess records a successful use of value, which makes a probed
+// value healthy again. Unknown values are ignored.
+func (h *HealthIterator[T]) ReportSuccess(value T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.find(value)
+	if item == nil {
+		return
+	}
+	h.refresh(item, h.cfg.now())
+	item.failures = 0
+	if item.state == HalfOpen {
+		item.state = Healthy
+		item.ejections = 0
+	}
+}
+
+// ReportFailure records a failed use of value, which ejects it after too
+// many consecutive failures, or at once if it was probed. Unknown values
+// are ignored.
+func (h *HealthIterator[T]) ReportFailure(value T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.find(value)
+	if item == nil {
+		return
+	}
+	h.refresh(item, h.cfg.now())
+	item.failures++
+
+	switch item.state {
+	case Healthy:
+		if item.failures >= h.cfg.threshold {
+			h.eject(item)
+		}
+	case HalfOpen:
+		h.eject(item)
+	}
+}
+
+// State returns the state of value, and false if value is unknown.
+func (h *HealthIterator[T]) State(value T) (HealthState, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.find(value)
+	if item == nil {
+		return Healthy, false
+	}
+	h.refresh(item, h.cfg.now())
+	return item.state, true
+}
+
+// refresh makes an ejected item half-open once its cooldown is over.
+func (h *HealthIterator[T]) refresh(item *healthItem[T], now time.Time) {
+	if item.state == Ejected && !now.Before(item.until) {
+		item.state = HalfOpen
+		item.probeAt = time.Time{}
+	}
+}
+
+func (h *HealthIterator[T]) eject(item *healthItem[T]) {
+	cooldown := h.cfg.minCooldown
+	for i := 0; i < item.ejections && cooldown < h.cfg.maxCooldown; i++ {
+		cooldown *= 2
+	}
+	if cooldown > h.cfg.maxCooldown {
+		cooldown = h.cfg.maxCooldown
+	}
+
+	item.state = Ejected
+	item.ejections++
+	item.until = h.cfg.now().Add(cooldown)
+}
+
+func (h *HealthIterator[T]) find(value T) *healthItem[T] {
+	for i := range h.items {
+		if h.items[i].value == value {
+			return &h.items[i]
+		}
+	}
+	return nil
+}
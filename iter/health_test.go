@@ -0,0 +1,100 @@
+package iter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNow struct{ t time.Time }
+
+func (f *fakeNow) now() time.Time          { return f.t }
+func (f *fakeNow) advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func TestHealthIterator_Eject(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	h := NewHealthIterator([]string{"a", "b", "c"},
+		WithFailureThreshold(2), WithCooldown(time.Second, 3*time.Second), WithNow(clock.now))
+
+	h.ReportFailure("b")
+	h.ReportSuccess("b")
+	h.ReportFailure("b")
+	state, _ := h.State("b")
+	assert.Equal(t, Healthy, state, "Expected a success to reset the failures")
+
+	h.ReportFailure("b")
+	state, _ = h.State("b")
+	assert.Equal(t, Ejected, state)
+	assert.Equal(t, []string{"a", "c", "a", "c"}, Collect(Take[string](h, 4)))
+
+	// The cooldown is over, b is probed once.
+	clock.advance(time.Second)
+	assert.Equal(t, []string{"a", "b", "c", "a", "c"}, Collect(Take[string](h, 5)))
+
+	// The probe fails, the cooldown doubles.
+	h.ReportFailure("b")
+	clock.advance(time.Second)
+	assert.Equal(t, []string{"a", "c"}, Collect(Take[string](h, 2)))
+	clock.advance(time.Second)
+	assert.Equal(t, []string{"a", "b", "c"}, Collect(Take[string](h, 3)))
+
+	// The probe succeeds.
+	h.ReportSuccess("b")
+	state, _ = h.State("b")
+	assert.Equal(t, Healthy, state)
+	assert.Equal(t, []string{"a", "b", "c"}, Collect(Take[string](h, 3)))
+}
+
+func TestHealthIterator_CooldownCap(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	h := NewHealthIterator([]int{1, 2},
+		WithFailureThreshold(1), WithCooldown(time.Second, 3*time.Second), WithNow(clock.now))
+
+	for _, cooldown := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		h.ReportFailure(1)
+		clock.advance(cooldown - time.Millisecond)
+		state, _ := h.State(1)
+		assert.Equal(t, Ejected, state, "Expected a cooldown of %v", cooldown)
+		clock.advance(time.Millisecond)
+		state, _ = h.State(1)
+		assert.Equal(t, HalfOpen, state)
+	}
+}
+
+func TestHealthIterator_AllDown(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	values := []string{"a", "b"}
+
+	open := NewHealthIterator(values, WithFailureThreshold(1), WithNow(clock.now))
+	closed := NewHealthIterator(values, WithFailureThreshold(1), WithNow(clock.now), WithFailOpen(false))
+	for _, v := range values {
+		open.ReportFailure(v)
+		closed.ReportFailure(v)
+	}
+
+	assert.Equal(t, []string{"a", "b", "a"}, Collect(Take[string](open, 3)), "Expected fail open to keep rotating")
+
+	_, ok := closed.Next()
+	assert.False(t, ok, "Expected nothing while every value is ejected")
+
+	_, ok = NewHealthIterator[string](nil).Next()
+	assert.False(t, ok)
+}
+
+func TestHealthIterator_LostProbe(t *testing.T) {
+	clock := &fakeNow{t: time.Unix(0, 0)}
+	h := NewHealthIterator([]string{"a", "b"}, WithFailureThreshold(1), WithNow(clock.now))
+
+	h.ReportFailure("a")
+	clock.advance(time.Second)
+	assert.Equal(t, []string{"a", "b", "b"}, Collect(Take[string](h, 3)))
+
+	// Nobody reported the probe, another one is due after the cooldown.
+	clock.advance(time.Second)
+	assert.Equal(t, []string{"a", "b"}, Collect(Take[string](h, 2)))
+
+	_, known := h.State("x")
+	assert.False(t, known)
+	assert.Equal(t, "half-open", HalfOpen.String())
+}
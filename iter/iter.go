@@ -1,6 +1,7 @@
 package iter
 
 // LoopIteratorr represents an endless/loop iterator for a generic array.
+// It is not safe for concurrent use, see Balancer for that.
 type LoopIteratorr[T any] struct {
 	data  []T
 	index int
@@ -22,7 +23,20 @@ func (iter *LoopIteratorr[T]) Reset() {
 	iter.index = 0
 }
 
+// Iter returns an endless Iterator over the loop. It is empty if the
+// LoopIteratorr has no data.
+func (iter *LoopIteratorr[T]) Iter() Iterator[T] {
+	return IteratorFunc[T](func() (T, bool) {
+		if len(iter.data) == 0 {
+			var zero T
+			return zero, false
+		}
+		return iter.Next(), true
+	})
+}
+
 // IntRangeIterator is a type for iterating over a range of integers.
+// It implements Iterator[int].
 type IntRangeIterator struct {
 	Start, End, Current int
 }
@@ -66,3 +80,12 @@ func (it *LoopingIntRangeIterator) Next() int {
 func (it *LoopingIntRangeIterator) Reset() {
 	it.Current = it.Start - 1
 }
+
+// Iter returns an endless Iterator over the range.
+func (it *LoopingIntRangeIterator) Iter() Iterator[int] {
+	return IteratorFunc[int](func() (int, bool) {
+		return it.Next(), true
+	})
+}
+
+var _ Iterator[int] = (*IntRangeIterator)(nil)
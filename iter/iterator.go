@@ -0,0 +1,191 @@
+package iter
+
+// Iterator is a sequence of values. Next returns the next value and true,
+// or the zero value and false once the sequence is exhausted.
+type Iterator[T any] interface {
+	Next() (T, bool)
+}
+
+// IteratorFunc adapts a function to the Iterator interface.
+type IteratorFunc[T any] func() (T, bool)
+
+// Next calls f.
+func (f IteratorFunc[T]) Next() (T, bool) {
+	return f()
+}
+
+// Pair holds two values, e.g. the values zipped together or an index with
+// its value.
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// FromSlice returns an iterator over the elements of data.
+func FromSlice[T any](data []T) Iterator[T] {
+	i := 0
+	return IteratorFunc[T](func() (T, bool) {
+		if i >= len(data) {
+			var zero T
+			return zero, false
+		}
+		v := data[i]
+		i++
+		return v, true
+	})
+}
+
+// Map returns an iterator which yields fn applied to every value of it.
+func Map[T, U any](it Iterator[T], fn func(T) U) Iterator[U] {
+	return IteratorFunc[U](func() (U, bool) {
+		v, ok := it.Next()
+		if !ok {
+			var zero U
+			return zero, false
+		}
+		return fn(v), true
+	})
+}
+
+// Filter returns an iterator which yields the values of it for which pred
+// returns true.
+func Filter[T any](it Iterator[T], pred func(T) bool) Iterator[T] {
+	return IteratorFunc[T](func() (T, bool) {
+		for {
+			v, ok := it.Next()
+			if !ok || pred(v) {
+				return v, ok
+			}
+		}
+	})
+}
+
+// Take returns an iterator which yields at most n values of it.
+func Take[T any](it Iterator[T], n int) Iterator[T] {
+	return IteratorFunc[T](func() (T, bool) {
+		if n <= 0 {
+			var zero T
+			return zero, false
+		}
+		n--
+		return it.Next()
+	})
+}
+
+// Skip returns an iterator which drops the first n values of it.
+func Skip[T any](it Iterator[T], n int) Iterator[T] {
+	return IteratorFunc[T](func() (T, bool) {
+		for ; n > 0; n-- {
+			if _, ok := it.Next(); !ok {
+				n = 0
+				var zero T
+				return zero, false
+			}
+		}
+		return it.Next()
+	})
+}
+
+// TakeWhile returns an iterator which yields the values of it until pred
+// returns false for the first time.
+func TakeWhile[T any](it Iterator[T], pred func(T) bool) Iterator[T] {
+	done := false
+	return IteratorFunc[T](func() (T, bool) {
+		var zero T
+		if done {
+			return zero, false
+		}
+		v, ok := it.Next()
+		if !ok || !pred(v) {
+			done = true
+			return zero, false
+		}
+		return v, true
+	})
+}
+
+// Zip returns an iterator which yields pairs of values of a and b. It stops
+// as soon as either of them is exhausted.
+func Zip[A, B any](a Iterator[A], b Iterator[B]) Iterator[Pair[A, B]] {
+	return IteratorFunc[Pair[A, B]](func() (Pair[A, B], bool) {
+		va, ok := a.Next()
+		if !ok {
+			return Pair[A, B]{}, false
+		}
+		vb, ok := b.Next()
+		if !ok {
+			return Pair[A, B]{}, false
+		}
+		return Pair[A, B]{First: va, Second: vb}, true
+	})
+}
+
+// Chain returns an iterator which yields the values of every iterator in
+// turn.
+func Chain[T any](its ...Iterator[T]) Iterator[T] {
+	return IteratorFunc[T](func() (T, bool) {
+		for len(its) > 0 {
+			if v, ok := its[0].Next(); ok {
+				return v, true
+			}
+			its = its[1:]
+		}
+		var zero T
+		return zero, false
+	})
+}
+
+// Chunk returns an iterator which yields the values of it in slices of the
+// given size. The last chunk may be shorter. Chunk panics if size is less
+// than 1.
+func Chunk[T any](it Iterator[T], size int) Iterator[[]T] {
+	if size < 1 {
+		panic("iter: chunk size must be positive")
+	}
+	return IteratorFunc[[]T](func() ([]T, bool) {
+		var chunk []T
+		for len(chunk) < size {
+			v, ok := it.Next()
+			if !ok {
+				break
+			}
+			chunk = append(chunk, v)
+		}
+		return chunk, len(chunk) > 0
+	})
+}
+
+// Enumerate returns an iterator which yields the values of it with their
+// zero based index.
+func Enumerate[T any](it Iterator[T]) Iterator[Pair[int, T]] {
+	i := 0
+	return IteratorFunc[Pair[int, T]](func() (Pair[int, T], bool) {
+		v, ok := it.Next()
+		if !ok {
+			return Pair[int, T]{}, false
+		}
+		p := Pair[int, T]{First: i, Second: v}
+		i++
+		return p, true
+	})
+}
+
+// Collect returns all the remaining values of it. It never returns for an
+// endless iterator, limit those with Take or TakeWhile first.
+func Collect[T any](it Iterator[T]) []T {
+	var out []T
+	for v, ok := it.Next(); ok; v, ok = it.Next() {
+		out = append(out, v)
+	}
+	return out
+}
+
+// Reduce folds the remaining values of it into a single value, starting
+// with init.
+func Reduce[T, U any](it Iterator[T], init U, fn func(U, T) U) U {
+	acc := init
+	for v, ok := it.Next(); ok; v, ok = it.Next() {
+		acc = fn(acc, v)
+	}
+	return acc
+}
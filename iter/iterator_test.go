@@ -0,0 +1,93 @@
+package iter
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromSlice(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, Collect(FromSlice([]int{1, 2, 3})))
+	assert.Empty(t, Collect(FromSlice[int](nil)))
+}
+
+func TestMapFilter(t *testing.T) {
+	it := Map(Filter[int](NewIntRangeIterator(1, 10), func(v int) bool { return v%3 == 0 }), strconv.Itoa)
+
+	assert.Equal(t, []string{"3", "6", "9"}, Collect(it))
+}
+
+func TestTakeSkip(t *testing.T) {
+	it := Take(Skip(NewLoopingIntRangeIterator(1, 3).Iter(), 2), 4)
+	assert.Equal(t, []int{3, 1, 2, 3}, Collect(it))
+
+	assert.Empty(t, Collect(Skip(FromSlice([]int{1, 2}), 5)), "Expected skipping past the end to be empty")
+	assert.Empty(t, Collect(Take(FromSlice([]int{1, 2}), 0)))
+}
+
+func TestTakeWhile(t *testing.T) {
+	it := TakeWhile(FromSlice([]int{1, 2, 5, 1}), func(v int) bool { return v < 3 })
+
+	assert.Equal(t, []int{1, 2}, Collect(it))
+	_, ok := it.Next()
+	assert.False(t, ok, "Expected TakeWhile to stay exhausted")
+}
+
+func TestZip(t *testing.T) {
+	it := Zip(FromSlice([]string{"a", "b", "c"}), NewLoopIterator([]int{1, 2}).Iter())
+
+	assert.Equal(t, []Pair[string, int]{{"a", 1}, {"b", 2}, {"c", 1}}, Collect(it))
+	assert.Empty(t, Collect(Zip(FromSlice([]int{1}), FromSlice[int](nil))))
+}
+
+func TestChain(t *testing.T) {
+	it := Chain(FromSlice([]int{1}), FromSlice[int](nil), Iterator[int](NewIntRangeIterator(2, 3)))
+
+	assert.Equal(t, []int{1, 2, 3}, Collect(it))
+}
+
+func TestChunk(t *testing.T) {
+	it := Chunk[int](NewIntRangeIterator(1, 5), 2)
+
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, Collect(it))
+	assert.Panics(t, func() { Chunk(FromSlice([]int{1}), 0) })
+}
+
+func TestEnumerate(t *testing.T) {
+	it := Enumerate(FromSlice([]string{"a", "b"}))
+
+	assert.Equal(t, []Pair[int, string]{{0, "a"}, {1, "b"}}, Collect(it))
+}
+
+func TestReduce(t *testing.T) {
+	sum := Reduce[int](NewIntRangeIterator(1, 4), 0, func(acc, v int) int { return acc + v })
+	assert.Equal(t, 10, sum)
+
+	s := Reduce(FromSlice([]int{1, 2}), "", func(acc string, v int) string { return acc + strconv.Itoa(v) })
+	assert.Equal(t, "12", s)
+}
+
+func TestLoopIterator_Iter(t *testing.T) {
+	_, ok := NewLoopIterator[int](nil).Iter().Next()
+	assert.False(t, ok, "Expected an empty loop to be exhausted")
+}
+
+func TestDirIterator_Iter(t *testing.T) {
+	rootDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(rootDir+"/a", 0o755))
+	assert.NoError(t, os.Mkdir(rootDir+"/b", 0o755))
+
+	iter, err := NewDirIterator(rootDir)
+	assert.NoError(t, err)
+
+	it := iter.Iter()
+	assert.Equal(t, []string{"a", "b", "a"}, Collect(Take(it, 3)))
+	assert.NoError(t, iter.Err())
+
+	assert.NoError(t, os.RemoveAll(rootDir))
+	_, ok := it.Next()
+	assert.False(t, ok)
+	assert.Error(t, iter.Err())
+}
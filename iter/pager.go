@@ -0,0 +1,157 @@
+package iter
+
+import (
+	"context"
+	"sync"
+)
+
+// Page is a page of items returned by a FetchFunc.
+type Page[T any] struct {
+	Items []T
+	// Next is the cursor of the next page. It is empty on the last page.
+	Next string
+}
+
+// FetchFunc fetches the page at cursor. The cursor of the first page is
+// empty, unless set with WithStartCursor.
+type FetchFunc[T any] func(ctx context.Context, cursor string) (Page[T], error)
+
+// PagerOption configures a Pager.
+type PagerOption func(*pagerConfig)
+
+type pagerConfig struct {
+	prefetch bool
+	start    string
+}
+
+// WithPrefetch fetches the next page in the background while the items of
+// the current one are returned.
+func WithPrefetch() PagerOption {
+	return func(c *pagerConfig) {
+		c.prefetch = true
+	}
+}
+
+// WithStartCursor starts at the page of cursor, e.g. to resume a walk.
+func WithStartCursor(cursor string) PagerOption {
+	return func(c *pagerConfig) {
+		c.start = cursor
+	}
+}
+
+type pageResult[T any] struct {
+	page Page[T]
+	err  error
+}
+
+// Pager returns the items of a paginated API one at a time, fetching the
+// pages as they are needed. It stops at the first error of the fetch
+// function or of the context, which is reported by Err. It is not safe for
+// concurrent use. Pager implements Iterator[T].
+type Pager[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	fetch  FetchFunc[T]
+	cfg    pagerConfig
+
+	items   []T
+	cursor  string // The cursor of the next page.
+	last    bool   // The last page has been fetched.
+	pending chan pageResult[T]
+	wg      sync.WaitGroup
+	closed  bool
+	err     error
+}
+
+// NewPager creates a new Pager calling fetch for the pages. The context is
+// passed to fetch.
+func NewPager[T any](ctx context.Context, fetch FetchFunc[T], opts ...PagerOption) *Pager[T] {
+	p := &Pager[T]{fetch: fetch}
+	for _, opt := range opts {
+		opt(&p.cfg)
+	}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.cursor = p.cfg.start
+	return p
+}
+
+// Next returns the next item. It returns false after the last page, after
+// an error or after Close.
+func (p *Pager[T]) Next() (T, bool) {
+	var zero T
+	for {
+		if p.err != nil || p.closed {
+			return zero, false
+		}
+		if err := p.ctx.Err(); err != nil {
+			p.err = err
+			return zero, false
+		}
+		if len(p.items) > 0 {
+			break
+		}
+		if p.last {
+			return zero, false
+		}
+		p.load()
+	}
+
+	v := p.items[0]
+	p.items[0] = zero
+	p.items = p.items[1:]
+	return v, true
+}
+
+// Err returns the error which stopped the iteration.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// Close stops the iteration and waits for a page being prefetched.
+func (p *Pager[T]) Close() error {
+	p.closed = true
+	p.cancel()
+	p.wg.Wait()
+	p.items = nil
+	return nil
+}
+
+// load gets the next page, from the prefetch if there is one.
+func (p *Pager[T]) load() {
+	var res pageResult[T]
+	if p.pending != nil {
+		select {
+		case res = <-p.pending:
+		case <-p.ctx.Done():
+			res.err = p.ctx.Err()
+		}
+		p.pending = nil
+	} else {
+		res.page, res.err = p.fetch(p.ctx, p.cursor)
+	}
+	if res.err != nil {
+		p.err = res.err
+		return
+	}
+
+	p.items = res.page.Items
+	p.cursor = res.page.Next
+	p.last = p.cursor == ""
+	if p.cfg.prefetch && !p.last {
+		p.prefetch()
+	}
+}
+
+func (p *Pager[T]) prefetch() {
+	ch := make(chan pageResult[T], 1)
+	p.pending = ch
+	cursor := p.cursor
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		var res pageResult[T]
+		res.page, res.err = p.fetch(p.ctx, cursor)
+		ch <- res
+	}()
+}
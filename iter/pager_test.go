@@ -0,0 +1,120 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAPI serves the pages of items, size items per page, with the index of
+// the page as cursor.
+type fakeAPI struct {
+	mu      sync.Mutex
+	items   []int
+	size    int
+	fail    string // The cursor which fails.
+	cursors []string
+}
+
+func (a *fakeAPI) fetch(ctx context.Context, cursor string) (Page[int], error) {
+	a.mu.Lock()
+	a.cursors = append(a.cursors, cursor)
+	a.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return Page[int]{}, err
+	}
+	if cursor == a.fail && cursor != "" {
+		return Page[int]{}, errors.New("fetch failed")
+	}
+
+	page := 0
+	if cursor != "" {
+		page, _ = strconv.Atoi(cursor)
+	}
+	start := min(page*a.size, len(a.items))
+	end := min(start+a.size, len(a.items))
+	p := Page[int]{Items: a.items[start:end]}
+	if end < len(a.items) {
+		p.Next = strconv.Itoa(page + 1)
+	}
+	return p, nil
+}
+
+func (a *fakeAPI) fetched() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string{}, a.cursors...)
+}
+
+func TestPager(t *testing.T) {
+	api := &fakeAPI{items: []int{1, 2, 3, 4, 5, 6, 7}, size: 3}
+	p := NewPager(context.Background(), api.fetch)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, Collect[int](p))
+	assert.NoError(t, p.Err())
+	assert.Equal(t, []string{"", "1", "2"}, api.fetched())
+
+	api = &fakeAPI{items: []int{1, 2, 3, 4, 5, 6, 7}, size: 3}
+	p = NewPager(context.Background(), api.fetch, WithStartCursor("1"))
+	assert.Equal(t, []int{4, 5, 6, 7}, Collect[int](p))
+
+	empty := &fakeAPI{size: 3}
+	p = NewPager(context.Background(), empty.fetch)
+	assert.Empty(t, Collect[int](p))
+	assert.NoError(t, p.Err())
+}
+
+func TestPager_Error(t *testing.T) {
+	for _, opts := range [][]PagerOption{nil, {WithPrefetch()}} {
+		api := &fakeAPI{items: []int{1, 2, 3, 4, 5}, size: 2, fail: "1"}
+		p := NewPager(context.Background(), api.fetch, opts...)
+		assert.Equal(t, []int{1, 2}, Collect[int](p))
+		assert.EqualError(t, p.Err(), "fetch failed")
+
+		_, ok := p.Next()
+		assert.False(t, ok, "Expected the iteration to stop at the error")
+		assert.NoError(t, p.Close())
+	}
+}
+
+func TestPager_Prefetch(t *testing.T) {
+	api := &fakeAPI{items: []int{1, 2, 3, 4, 5}, size: 2}
+	p := NewPager(context.Background(), api.fetch, WithPrefetch())
+
+	v, ok := p.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Eventually(t, func() bool { return len(api.fetched()) == 2 }, time.Second, time.Millisecond,
+		"Expected the next page to be fetched in the background")
+
+	assert.Equal(t, []int{2, 3, 4, 5}, Collect[int](p))
+	assert.NoError(t, p.Err())
+	assert.Equal(t, []string{"", "1", "2"}, api.fetched())
+	assert.NoError(t, p.Close())
+}
+
+func TestPager_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	api := &fakeAPI{items: []int{1, 2, 3, 4, 5}, size: 2}
+	p := NewPager(ctx, api.fetch, WithPrefetch())
+
+	v, _ := p.Next()
+	assert.Equal(t, 1, v)
+	cancel()
+	_, ok := p.Next()
+	assert.False(t, ok)
+	assert.ErrorIs(t, p.Err(), context.Canceled)
+	assert.NoError(t, p.Close())
+
+	p = NewPager(context.Background(), api.fetch, WithPrefetch())
+	p.Next()
+	assert.NoError(t, p.Close())
+	_, ok = p.Next()
+	assert.False(t, ok)
+	assert.NoError(t, p.Err(), "Expected Close not to be reported as an error")
+}
@@ -0,0 +1,253 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ParallelOption configures ParallelMap.
+type ParallelOption func(*parallelConfig)
+
+type parallelConfig struct {
+	workers       int
+	inFlight      int
+	collectErrors bool
+}
+
+// WithWorkers sets the number of concurrent calls of the function. The
+// default is runtime.GOMAXPROCS(0).
+func WithWorkers(n int) ParallelOption {
+	return func(c *parallelConfig) {
+		c.workers = n
+	}
+}
+
+// WithMaxInFlight sets how many items may be read from the source and not
+// returned yet. The default is twice the number of workers.
+func WithMaxInFlight(n int) ParallelOption {
+	return func(c *parallelConfig) {
+		c.inFlight = n
+	}
+}
+
+// CollectErrors skips the items which failed instead of stopping at the
+// first error. Err then returns the errors of all of them.
+func CollectErrors() ParallelOption {
+	return func(c *parallelConfig) {
+		c.collectErrors = true
+	}
+}
+
+type parallelResult[R any] struct {
+	value R
+	err   error
+}
+
+type parallelJob[T, R any] struct {
+	index int
+	value T
+	slot  chan parallelResult[R]
+}
+
+// ParallelIterator returns the results of ParallelMap in the order of the
+// input. It is not safe for concurrent use. ParallelIterator implements
+// Iterator[R].
+type ParallelIterator[R any] struct {
+	parent context.Context
+	cancel context.CancelFunc
+	cfg    parallelConfig
+	order  chan chan parallelResult[R] // The pending results in input order.
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	running  map[int]context.CancelFunc // The contexts of the running items by index.
+	failAt   int                        // The index of the first failed item, or -1.
+	failed   chan struct{}              // Closed at the first failure.
+	failOnce sync.Once
+
+	index int
+	errs  []error
+	done  bool
+}
+
+// ParallelMap calls fn for the items of src with several workers and
+// returns the results in the order of src. The context passed to fn is
+// cancelled when the iteration stops. By default the iteration stops at
+// the first failed item, after the results before it, and only the items
+// after it are cancelled; with CollectErrors failed items are skipped. src
+// is only read by one goroutine. The iterator must be closed with Close
+// unless it is read to the end.
+func ParallelMap[T, R any](ctx context.Context, src Iterator[T], fn func(context.Context, T) (R, error), opts ...ParallelOption) *ParallelIterator[R] {
+	cfg := parallelConfig{workers: runtime.GOMAXPROCS(0)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.workers = max(cfg.workers, 1)
+	if cfg.inFlight == 0 {
+		cfg.inFlight = 2 * cfg.workers
+	}
+	cfg.inFlight = max(cfg.inFlight, 1)
+
+	p := &ParallelIterator[R]{
+		parent: ctx,
+		cfg:    cfg,
+		order:  make(chan chan parallelResult[R], cfg.inFlight),
+		sem:    make(chan struct{}, cfg.inFlight),
+
+		running: make(map[int]context.CancelFunc),
+		failAt:  -1,
+		failed:  make(chan struct{}),
+	}
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	jobs := make(chan parallelJob[T, R])
+	p.wg.Add(1 + cfg.workers)
+	go func() {
+		defer p.wg.Done()
+		defer close(jobs)
+		defer close(p.order)
+		for index := 0; ; index++ {
+			select {
+			case p.sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			case <-p.failed:
+				return
+			}
+			v, ok := src.Next()
+			if !ok {
+				return
+			}
+
+			slot := make(chan parallelResult[R], 1)
+			p.order <- slot
+			select {
+			case jobs <- parallelJob[T, R]{index: index, value: v, slot: slot}:
+			case <-ctx.Done():
+				slot <- parallelResult[R]{err: ctx.Err()}
+				return
+			case <-p.failed:
+				slot <- parallelResult[R]{err: context.Canceled}
+				return
+			}
+		}
+	}()
+	for i := 0; i < cfg.workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range jobs {
+				jobCtx, ok := p.start(ctx, job.index)
+				if !ok {
+					job.slot <- parallelResult[R]{err: context.Canceled}
+					continue
+				}
+				v, err := fn(jobCtx, job.value)
+				p.finish(job.index)
+				if err != nil && !cfg.collectErrors {
+					p.fail(job.index)
+				}
+				job.slot <- parallelResult[R]{value: v, err: err}
+			}
+		}()
+	}
+	return p
+}
+
+// Next returns the next result in input order. It returns false at the end
+// of the input, at the first error unless errors are collected, or when the
+// context is cancelled.
+func (p *ParallelIterator[R]) Next() (R, bool) {
+	var zero R
+	for !p.done {
+		if err := p.parent.Err(); err != nil {
+			p.errs = append(p.errs, err)
+			p.stop()
+			break
+		}
+
+		slot, ok := <-p.order
+		if !ok {
+			p.stop()
+			break
+		}
+		res := <-slot
+		<-p.sem
+		index := p.index
+		p.index++
+
+		switch {
+		case res.err == nil:
+			return res.value, true
+		case p.parent.Err() != nil:
+			p.errs = append(p.errs, p.parent.Err())
+			p.stop()
+		case !p.cfg.collectErrors:
+			p.errs = append(p.errs, res.err)
+			p.stop()
+		default:
+			p.errs = append(p.errs, fmt.Errorf("item %d: %w", index, res.err))
+		}
+	}
+	return zero, false
+}
+
+// Err returns the error which stopped the iteration, or the errors of all
+// failed items with CollectErrors.
+func (p *ParallelIterator[R]) Err() error {
+	return errors.Join(p.errs...)
+}
+
+// Close stops the iteration and waits for the workers to return.
+func (p *ParallelIterator[R]) Close() error {
+	p.stop()
+	p.wg.Wait()
+	return nil
+}
+
+func (p *ParallelIterator[R]) stop() {
+	p.done = true
+	p.cancel()
+}
+
+// start returns the context of the item at index. It returns false if the
+// item comes after a failed one and must not run.
+func (p *ParallelIterator[R]) start(ctx context.Context, index int) (context.Context, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failAt >= 0 && index > p.failAt {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p.running[index] = cancel
+	return ctx, true
+}
+
+// finish releases the context of the item at index.
+func (p *ParallelIterator[R]) finish(index int) {
+	p.mu.Lock()
+	cancel := p.running[index]
+	delete(p.running, index)
+	p.mu.Unlock()
+	cancel()
+}
+
+// fail stops reading the source and cancels the items after the failed one
+// at index. The items before it go on, as their results are still returned.
+func (p *ParallelIterator[R]) fail(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failAt >= 0 && index >= p.failAt {
+		return
+	}
+	p.failAt = index
+	for i, cancel := range p.running {
+		if i > index {
+			cancel()
+		}
+	}
+	p.failOnce.Do(func() { close(p.failed) })
+}
@@ -0,0 +1,137 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sleepy(_ context.Context, v int) (string, error) {
+	time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond) //#nosec G404 -- Test jitter.
+	return strconv.Itoa(v), nil
+}
+
+func TestParallelMap(t *testing.T) {
+	data := make([]int, 100)
+	want := make([]string, len(data))
+	for i := range data {
+		data[i] = i
+		want[i] = strconv.Itoa(i)
+	}
+
+	p := ParallelMap(context.Background(), FromSlice(data), sleepy, WithWorkers(8))
+	assert.Equal(t, want, Collect[string](p), "Expected the results in input order")
+	assert.NoError(t, p.Err())
+	assert.NoError(t, p.Close())
+
+	p = ParallelMap(context.Background(), FromSlice([]int{}), sleepy)
+	assert.Empty(t, Collect[string](p))
+	assert.NoError(t, p.Err())
+}
+
+func TestParallelMap_InFlight(t *testing.T) {
+	var read, returned, over atomic.Int64
+	i := 0
+	src := IteratorFunc[int](func() (int, bool) {
+		if read.Add(1)-returned.Load() > 4 {
+			over.Add(1)
+		}
+		i++
+		return i, i <= 50
+	})
+
+	p := ParallelMap(context.Background(), src, sleepy, WithWorkers(3), WithMaxInFlight(4))
+	n := 0
+	for _, ok := p.Next(); ok; _, ok = p.Next() {
+		returned.Add(1)
+		n++
+	}
+	assert.Equal(t, 50, n)
+	assert.Zero(t, over.Load(), "Expected at most 4 items in flight")
+}
+
+func TestParallelMap_Errors(t *testing.T) {
+	failing := func(ctx context.Context, v int) (int, error) {
+		if v%10 == 5 {
+			return 0, errors.New("bad " + strconv.Itoa(v))
+		}
+		return v, ctx.Err()
+	}
+	data := []int{1, 2, 3, 4, 5, 6, 7, 15}
+
+	p := ParallelMap(context.Background(), FromSlice(data), failing, WithWorkers(1))
+	assert.Equal(t, []int{1, 2, 3, 4}, Collect[int](p), "Expected the results before the error")
+	assert.EqualError(t, p.Err(), "bad 5")
+	_, ok := p.Next()
+	assert.False(t, ok)
+	assert.NoError(t, p.Close())
+
+	p = ParallelMap(context.Background(), FromSlice(data), failing, WithWorkers(4), CollectErrors())
+	assert.Equal(t, []int{1, 2, 3, 4, 6, 7}, Collect[int](p), "Expected failed items to be skipped")
+	assert.EqualError(t, p.Err(), "item 4: bad 5\nitem 7: bad 15")
+}
+
+func TestParallelMap_ErrorKeepsEarlier(t *testing.T) {
+	fn := func(ctx context.Context, v int) (int, error) {
+		switch v {
+		case 0:
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		case 2:
+			return 0, errors.New("boom")
+		case 3:
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return v, nil
+	}
+
+	p := ParallelMap(context.Background(), FromSlice([]int{0, 1, 2, 3}), fn, WithWorkers(4))
+	assert.Equal(t, []int{0, 1}, Collect[int](p), "Expected the earlier items to finish")
+	assert.EqualError(t, p.Err(), "boom")
+	assert.NoError(t, p.Close())
+}
+
+func TestParallelMap_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	blocking := func(ctx context.Context, v int) (int, error) {
+		if v > 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return v, nil
+	}
+
+	p := ParallelMap(ctx, FromSlice([]int{1, 2, 3, 4}), blocking, WithWorkers(2))
+	v, ok := p.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	cancel()
+	_, ok = p.Next()
+	assert.False(t, ok)
+	assert.ErrorIs(t, p.Err(), context.Canceled)
+	assert.NoError(t, p.Close())
+
+	endless := IteratorFunc[int](func() (int, bool) { return 2, true })
+	p = ParallelMap(context.Background(), endless, blocking, WithWorkers(2))
+	done := make(chan struct{})
+	go func() {
+		assert.NoError(t, p.Close())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Close to stop the workers")
+	}
+	assert.NoError(t, p.Err(), "Expected Close not to be reported as an error")
+}
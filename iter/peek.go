@@ -0,0 +1,85 @@
+package iter
+
+import "errors"
+
+// ErrPeekFull is returned by Unread when the buffer of a Peekable is full.
+var ErrPeekFull = errors.New("iter: peek buffer full")
+
+// Peekable wraps an iterator with lookahead and pushback, e.g. for parsers.
+// Peeked and unread values are held in a buffer of a fixed size. It is not
+// safe for concurrent use. Peekable implements Iterator[T].
+type Peekable[T any] struct {
+	src  Iterator[T]
+	buf  []T // The values to return before reading src.
+	size int
+}
+
+// NewPeekable returns a Peekable over src buffering up to size values. It
+// panics if size is not positive.
+func NewPeekable[T any](src Iterator[T], size int) *Peekable[T] {
+	if size <= 0 {
+		panic("iter: peek buffer size must be positive")
+	}
+	return &Peekable[T]{src: src, buf: make([]T, 0, size), size: size}
+}
+
+// Next returns the next value, from the buffer if it is not empty.
+func (p *Peekable[T]) Next() (T, bool) {
+	if len(p.buf) > 0 {
+		v := p.buf[0]
+		last := len(p.buf) - 1
+		copy(p.buf, p.buf[1:])
+		// Drop the reference left behind the shifted values.
+		var zero T
+		p.buf[last] = zero
+		p.buf = p.buf[:last]
+		return v, true
+	}
+	return p.src.Next()
+}
+
+// Peek returns the next value without consuming it. It returns false at the
+// end of the iterator.
+func (p *Peekable[T]) Peek() (T, bool) {
+	if !p.fill(1) {
+		var zero T
+		return zero, false
+	}
+	return p.buf[0], true
+}
+
+// PeekN returns up to n next values without consuming them. It returns
+// fewer values at the end of the iterator. It panics if n is negative or
+// larger than the buffer.
+func (p *Peekable[T]) PeekN(n int) []T {
+	if n < 0 || n > p.size {
+		panic("iter: peek count out of range")
+	}
+	p.fill(n)
+	return append([]T(nil), p.buf[:min(n, len(p.buf))]...)
+}
+
+// Unread pushes v back, so it is the next value returned. It returns
+// ErrPeekFull if the buffer is full.
+func (p *Peekable[T]) Unread(v T) error {
+	if len(p.buf) == p.size {
+		return ErrPeekFull
+	}
+	p.buf = append(p.buf, v)
+	copy(p.buf[1:], p.buf)
+	p.buf[0] = v
+	return nil
+}
+
+// fill reads from src until the buffer holds n values. It returns false if
+// src ended first.
+func (p *Peekable[T]) fill(n int) bool {
+	for len(p.buf) < n {
+		v, ok := p.src.Next()
+		if !ok {
+			return false
+		}
+		p.buf = append(p.buf, v)
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package iter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeekable(t *testing.T) {
+	p := NewPeekable(FromSlice([]int{1, 2, 3, 4}), 3)
+
+	v, ok := p.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, []int{1, 2, 3}, p.PeekN(3))
+	assert.Equal(t, []int{1, 2}, p.PeekN(2))
+	assert.Empty(t, p.PeekN(0))
+
+	v, _ = p.Next()
+	assert.Equal(t, 1, v)
+	assert.Equal(t, []int{2, 3, 4}, p.PeekN(3))
+	assert.Equal(t, []int{2, 3, 4}, Collect[int](p))
+
+	_, ok = p.Peek()
+	assert.False(t, ok)
+	assert.Empty(t, p.PeekN(2), "Expected nothing to peek at the end")
+
+	assert.Panics(t, func() { p.PeekN(4) })
+	assert.Panics(t, func() { NewPeekable(FromSlice([]int{}), 0) })
+}
+
+func TestPeekable_Release(t *testing.T) {
+	x, y := 1, 2
+	p := NewPeekable(FromSlice([]*int{&x, &y}), 2)
+	assert.Len(t, p.PeekN(2), 2)
+
+	v, _ := p.Next()
+	assert.Same(t, &x, v)
+	assert.Equal(t, []*int{&y, nil}, p.buf[:2], "Expected no reference past the buffered values")
+}
+
+func TestPeekable_Unread(t *testing.T) {
+	p := NewPeekable(FromSlice([]string{"a", "b", "c"}), 2)
+
+	a, _ := p.Next()
+	b, _ := p.Next()
+	assert.NoError(t, p.Unread(b))
+	assert.NoError(t, p.Unread(a))
+	assert.ErrorIs(t, p.Unread("x"), ErrPeekFull)
+
+	assert.Equal(t, []string{"a", "b"}, p.PeekN(2))
+	assert.Equal(t, []string{"a", "b", "c"}, Collect[string](p))
+
+	assert.NoError(t, p.Unread("z"), "Expected Unread to work at the end")
+	v, ok := p.Next()
+	assert.True(t, ok)
+	assert.Equal(t, "z", v)
+}
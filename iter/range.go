@@ -0,0 +1,308 @@
+package iter
+
+import (
+	goiter "iter"
+	"math"
+)
+
+// Integer is the set of integer types.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is the set of floating point types.
+type Float interface {
+	~float32 | ~float64
+}
+
+// floatTolerance is the relative error below which a float range treats a
+// quotient as a whole number of steps, so that 0.3 is in 0..0.3 by 0.1.
+const floatTolerance = 1e-9
+
+// Range is an iterator over the values start, start+step, start+2*step...
+// up to end. A positive step counts up, a negative one counts down and the
+// range is empty when the step points away from end. Values are computed
+// from their position in the range, so iteration never overflows T and
+// float ranges do not accumulate rounding errors. Range implements
+// Iterator[T].
+type Range[T Integer | Float] struct {
+	first T
+	down  bool
+	umag  uint64  // the step magnitude of an integer range
+	fmag  float64 // the step magnitude of a float range
+	float bool
+	empty bool
+	last  uint64 // the position of the last value
+	pos   uint64 // the position of the next value
+	done  bool
+}
+
+// NewRange returns a range from start up to but excluding end. It panics
+// if step is zero.
+func NewRange[T Integer | Float](start, end, step T) *Range[T] {
+	return newRange(start, end, step, false)
+}
+
+// NewInclusiveRange returns a range from start up to and including end. It
+// panics if step is zero.
+func NewInclusiveRange[T Integer | Float](start, end, step T) *Range[T] {
+	return newRange(start, end, step, true)
+}
+
+func newRange[T Integer | Float](start, end, step T, inclusive bool) *Range[T] {
+	if step == 0 {
+		panic("iter: range step must not be zero")
+	}
+
+	r := &Range[T]{first: start, down: step < 0}
+	// Integer division truncates, float division does not.
+	if T(1)/T(2) != 0 {
+		r.float = true
+		r.initFloat(float64(end), float64(step), inclusive)
+	} else {
+		r.initInteger(end, step, inclusive)
+	}
+	return r
+}
+
+func (r *Range[T]) initInteger(end, step T, inclusive bool) {
+	// The conversions to uint64 keep the two's complement bits, so the
+	// differences below are exact even across the whole range of T.
+	var dist uint64
+	if r.down {
+		r.umag = -uint64(step)
+		if r.first < end {
+			r.empty = true
+			return
+		}
+		dist = uint64(r.first) - uint64(end)
+	} else {
+		r.umag = uint64(step)
+		if r.first > end {
+			r.empty = true
+			return
+		}
+		dist = uint64(end) - uint64(r.first)
+	}
+
+	switch {
+	case inclusive:
+		r.last = dist / r.umag
+	case dist == 0:
+		r.empty = true
+	default:
+		r.last = (dist - 1) / r.umag
+	}
+}
+
+func (r *Range[T]) initFloat(end, step float64, inclusive bool) {
+	r.fmag = math.Abs(step)
+
+	q := snap((end - float64(r.first)) / step)
+	switch {
+	case math.IsNaN(q) || q < 0 || (q == 0 && !inclusive):
+		r.empty = true
+	case q >= math.MaxUint64:
+		r.last = math.MaxUint64
+	case inclusive:
+		r.last = uint64(math.Floor(q))
+	default:
+		r.last = uint64(math.Ceil(q)) - 1
+	}
+}
+
+// snap rounds q to the nearest whole number if it is within the tolerance.
+func snap(q float64) float64 {
+	n := math.Round(q)
+	if math.Abs(q-n) <= floatTolerance*math.Max(1, math.Abs(n)) {
+		return n
+	}
+	return q
+}
+
+// value returns the value at position i.
+func (r *Range[T]) value(i uint64) T {
+	var offset T
+	if r.float {
+		offset = T(float64(i) * r.fmag)
+	} else {
+		offset = T(i * r.umag)
+	}
+
+	if r.down {
+		return r.first - offset
+	}
+	return r.first + offset
+}
+
+// Next returns the next value in the range and a boolean indicating if
+// there was one.
+func (r *Range[T]) Next() (T, bool) {
+	if r.empty || r.done {
+		var zero T
+		return zero, false
+	}
+
+	v := r.value(r.pos)
+	if r.pos == r.last {
+		r.done = true
+	} else {
+		r.pos++
+	}
+	return v, true
+}
+
+// Reset restarts the range from its first value.
+func (r *Range[T]) Reset() {
+	r.pos = 0
+	r.done = false
+}
+
+// Len returns the number of values in the range, saturating at
+// math.MaxInt.
+func (r *Range[T]) Len() int {
+	switch {
+	case r.empty:
+		return 0
+	case r.last >= math.MaxInt:
+		return math.MaxInt
+	}
+	return int(r.last) + 1
+}
+
+// Contains reports whether v is one of the values of the range.
+func (r *Range[T]) Contains(v T) bool {
+	if r.empty {
+		return false
+	}
+
+	if r.float {
+		q := (float64(v) - float64(r.first)) / r.fmag
+		if r.down {
+			q = -q
+		}
+		q = snap(q)
+		return q >= 0 && q == math.Trunc(q) && q <= float64(r.last)
+	}
+
+	var dist uint64
+	if r.down {
+		if v > r.first {
+			return false
+		}
+		dist = uint64(r.first) - uint64(v)
+	} else {
+		if v < r.first {
+			return false
+		}
+		dist = uint64(v) - uint64(r.first)
+	}
+	return dist%r.umag == 0 && dist/r.umag <= r.last
+}
+
+// Reverse returns a new range with the same values in reverse order.
+func (r *Range[T]) Reverse() *Range[T] {
+	rev := *r
+	rev.Reset()
+	if !r.empty {
+		rev.first = r.value(r.last)
+		rev.down = !r.down
+	}
+	return &rev
+}
+
+// Loop returns an endless range which starts over after its last value.
+func (r *Range[T]) Loop() *LoopingRange[T] {
+	l := &LoopingRange[T]{r: *r}
+	l.r.Reset()
+	return l
+}
+
+// Seq returns a push iterator over the rest of the range.
+func (r *Range[T]) Seq() goiter.Seq[T] {
+	return ToSeq[T](r)
+}
+
+// All is like Seq but also yields the position of every value in the
+// range.
+func (r *Range[T]) All() goiter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for {
+			i := int(r.pos)
+			v, ok := r.Next()
+			if !ok || !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// LoopingRange is a Range which starts over after its last value. Next only
+// returns false if the range is empty. LoopingRange implements Iterator[T].
+type LoopingRange[T Integer | Float] struct {
+	r Range[T]
+}
+
+// NewLoopingRange returns a looping range from start up to but excluding
+// end. It panics if step is zero.
+func NewLoopingRange[T Integer | Float](start, end, step T) *LoopingRange[T] {
+	return NewRange(start, end, step).Loop()
+}
+
+// NewLoopingInclusiveRange returns a looping range from start up to and
+// including end. It panics if step is zero.
+func NewLoopingInclusiveRange[T Integer | Float](start, end, step T) *LoopingRange[T] {
+	return NewInclusiveRange(start, end, step).Loop()
+}
+
+// Next returns the next value in the range, starting over after the last.
+func (l *LoopingRange[T]) Next() (T, bool) {
+	if l.r.done {
+		l.r.Reset()
+	}
+	return l.r.Next()
+}
+
+// Reset restarts the range from its first value.
+func (l *LoopingRange[T]) Reset() {
+	l.r.Reset()
+}
+
+// Len returns the number of values in one pass over the range.
+func (l *LoopingRange[T]) Len() int {
+	return l.r.Len()
+}
+
+// Contains reports whether v is one of the values of the range.
+func (l *LoopingRange[T]) Contains(v T) bool {
+	return l.r.Contains(v)
+}
+
+// Reverse returns a new looping range with the same values in reverse
+// order.
+func (l *LoopingRange[T]) Reverse() *LoopingRange[T] {
+	return l.r.Reverse().Loop()
+}
+
+// Seq returns an endless push iterator over the range.
+func (l *LoopingRange[T]) Seq() goiter.Seq[T] {
+	return ToSeq[T](l)
+}
+
+// All is like Seq but also yields the position of every value in the
+// range.
+func (l *LoopingRange[T]) All() goiter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for {
+			if l.r.done {
+				l.r.Reset()
+			}
+			i := int(l.r.pos)
+			v, ok := l.r.Next()
+			if !ok || !yield(i, v) {
+				return
+			}
+		}
+	}
+}
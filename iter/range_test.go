@@ -0,0 +1,114 @@
+package iter
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	assert.Equal(t, []int{0, 3, 6, 9}, Collect[int](NewRange(0, 10, 3)))
+	assert.Equal(t, []int{0, 3, 6, 9}, Collect[int](NewRange(0, 12, 3)), "Expected the end to be excluded")
+	assert.Equal(t, []int{0, 3, 6, 9, 12}, Collect[int](NewInclusiveRange(0, 12, 3)))
+	assert.Equal(t, []int{5, 3, 1}, Collect[int](NewRange(5, 0, -2)))
+	assert.Equal(t, []int{5}, Collect[int](NewInclusiveRange(5, 5, 1)))
+
+	assert.Empty(t, Collect[int](NewRange(5, 5, 1)))
+	assert.Empty(t, Collect[int](NewRange(0, 10, -1)), "Expected a step away from the end to be empty")
+	assert.Empty(t, Collect[uint](NewRange[uint](10, 0, 1)))
+
+	assert.Panics(t, func() { NewRange(0, 10, 0) })
+}
+
+func TestRange_Overflow(t *testing.T) {
+	assert.Equal(t, []int8{125, 126, 127}, Collect[int8](NewInclusiveRange[int8](125, math.MaxInt8, 1)))
+	assert.Equal(t, []int8{-126, -128}, Collect[int8](NewInclusiveRange[int8](-126, math.MinInt8, -2)))
+	assert.Equal(t, []uint8{250, 255}, Collect[uint8](NewInclusiveRange[uint8](250, 255, 5)))
+
+	r := NewInclusiveRange[int64](math.MinInt64, math.MaxInt64, math.MaxInt64)
+	assert.Equal(t, []int64{math.MinInt64, -1, math.MaxInt64 - 1}, Collect[int64](r))
+	assert.Equal(t, 3, r.Len())
+
+	assert.Equal(t, math.MaxInt, NewInclusiveRange[uint64](0, math.MaxUint64, 1).Len(), "Expected Len to saturate")
+}
+
+func TestRange_Float(t *testing.T) {
+	got := Collect[float64](NewInclusiveRange(0, 0.3, 0.1))
+	assert.Len(t, got, 4, "Expected the end to be reached despite rounding")
+	assert.InDelta(t, 0.3, got[3], 1e-12)
+
+	assert.Len(t, Collect[float64](NewRange(0, 0.3, 0.1)), 3)
+	assert.Equal(t, []float32{1, 0.5, 0}, Collect[float32](NewInclusiveRange[float32](1, 0, -0.5)))
+	assert.Equal(t, 3, NewRange(0, 1, 0.4).Len())
+}
+
+func TestRange_Contains(t *testing.T) {
+	r := NewRange(1, 10, 3)
+	assert.True(t, r.Contains(1))
+	assert.True(t, r.Contains(7))
+	assert.False(t, r.Contains(10))
+	assert.False(t, r.Contains(2))
+	assert.False(t, r.Contains(-2))
+
+	down := NewInclusiveRange[int8](math.MaxInt8, math.MinInt8, -5)
+	assert.True(t, down.Contains(math.MaxInt8-25))
+	assert.True(t, down.Contains(math.MinInt8))
+	assert.False(t, down.Contains(math.MinInt8+1))
+
+	f := NewInclusiveRange(0, 1, 0.1)
+	assert.True(t, f.Contains(0.7))
+	assert.False(t, f.Contains(0.75))
+	assert.False(t, f.Contains(1.1))
+
+	assert.False(t, NewRange(0, 0, 1).Contains(0))
+}
+
+func TestRange_Reverse(t *testing.T) {
+	r := NewRange(0, 10, 3)
+	r.Next()
+
+	rev := r.Reverse()
+	assert.Equal(t, []int{9, 6, 3, 0}, Collect[int](rev))
+	assert.True(t, rev.Contains(3))
+	assert.Equal(t, []int{3, 6, 9}, Collect[int](r), "Expected the original range to be unchanged")
+
+	assert.Equal(t, []uint{4, 2, 0}, Collect[uint](NewInclusiveRange[uint](0, 4, 2).Reverse()))
+	assert.Empty(t, Collect[int](NewRange(0, 0, 1).Reverse()))
+}
+
+func TestRange_Reset(t *testing.T) {
+	r := NewRange(0, 3, 1)
+	assert.Equal(t, []int{0, 1, 2}, slices.Collect(r.Seq()))
+
+	r.Reset()
+	var idx []int
+	for i, v := range r.All() {
+		idx = append(idx, i)
+		assert.Equal(t, i, v)
+	}
+	assert.Equal(t, []int{0, 1, 2}, idx)
+}
+
+func TestLoopingRange(t *testing.T) {
+	l := NewLoopingRange(0, 6, 2)
+	assert.Equal(t, []int{0, 2, 4, 0, 2, 4, 0}, Collect(Take[int](l, 7)))
+	assert.Equal(t, 3, l.Len())
+	assert.True(t, l.Contains(4))
+
+	l.Reset()
+	assert.Equal(t, []int{4, 2, 0, 4}, Collect(Take[int](l.Reverse(), 4)))
+
+	var idx []int
+	for i := range NewLoopingInclusiveRange(10, 20, 5).All() {
+		if len(idx) == 4 {
+			break
+		}
+		idx = append(idx, i)
+	}
+	assert.Equal(t, []int{0, 1, 2, 0}, idx)
+
+	_, ok := NewLoopingRange(0, 0, 1).Next()
+	assert.False(t, ok, "Expected an empty looping range to be exhausted")
+}
@@ -0,0 +1,200 @@
+package iter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/wormbks/dry/ioutils"
+)
+
+// RecordError is the error of a RecordIterator, with the line of the
+// record which caused it.
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// RecordOption configures a RecordIterator.
+type RecordOption func(*recordConfig)
+
+type recordConfig struct {
+	delim     string
+	maxSize   int
+	skipBlank bool
+	comma     rune
+}
+
+// WithDelimiter splits the records at delim instead of at line ends. Line
+// numbers then count records. An empty delim keeps the line ends.
+func WithDelimiter(delim string) RecordOption {
+	return func(c *recordConfig) {
+		c.delim = delim
+	}
+}
+
+// WithMaxRecordSize sets the size of the longest record which can be read.
+// The default is 64 MiB.
+func WithMaxRecordSize(n int) RecordOption {
+	return func(c *recordConfig) {
+		c.maxSize = n
+	}
+}
+
+// SkipBlankLines skips the records which only hold white space.
+func SkipBlankLines() RecordOption {
+	return func(c *recordConfig) {
+		c.skipBlank = true
+	}
+}
+
+// WithComma sets the field delimiter of a CSV iterator. The default is ','.
+func WithComma(r rune) RecordOption {
+	return func(c *recordConfig) {
+		c.comma = r
+	}
+}
+
+func newRecordConfig(opts []RecordOption) recordConfig {
+	c := recordConfig{delim: "\n", maxSize: 64 << 20, comma: ','}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.delim == "" {
+		c.delim = "\n"
+	}
+	return c
+}
+
+// RecordIterator decodes the records of a reader one by one. It stops at
+// the first error, which is reported by Err with the line of the record.
+// RecordIterator implements Iterator[T].
+type RecordIterator[T any] struct {
+	read   func() (T, bool, error)
+	line   func() int
+	closer io.Closer
+	err    error
+}
+
+// NewRecordIterator returns an iterator over the records of r, split at
+// line ends, or at the delimiter set by WithDelimiter, and converted with
+// decode. The line end is not part of the record.
+func NewRecordIterator[T any](r io.Reader, decode func(record []byte) (T, error), opts ...RecordOption) *RecordIterator[T] {
+	cfg := newRecordConfig(opts)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, min(64<<10, cfg.maxSize)), cfg.maxSize)
+	if cfg.delim != "\n" {
+		s.Split(splitOn([]byte(cfg.delim)))
+	}
+
+	line := 0
+	return &RecordIterator[T]{
+		read: func() (T, bool, error) {
+			var zero T
+			for s.Scan() {
+				line++
+				b := s.Bytes()
+				if cfg.skipBlank && len(bytes.TrimSpace(b)) == 0 {
+					continue
+				}
+				v, err := decode(b)
+				return v, err == nil, err
+			}
+			if err := s.Err(); err != nil {
+				// The record which failed to be read.
+				line++
+				return zero, false, err
+			}
+			return zero, false, nil
+		},
+		line: func() int { return line },
+	}
+}
+
+// OpenRecordIterator is like NewRecordIterator for the file at path, which
+// may be compressed with gzip. The file must be closed with Close.
+func OpenRecordIterator[T any](path string, decode func(record []byte) (T, error), opts ...RecordOption) (*RecordIterator[T], error) {
+	f, err := ioutils.NewGzipReader(path)
+	if err != nil {
+		return nil, err
+	}
+	it := NewRecordIterator(f, decode, opts...)
+	it.closer = f
+	return it, nil
+}
+
+// Next returns the next record. It returns false at the end of the input or
+// after an error.
+func (it *RecordIterator[T]) Next() (T, bool) {
+	var zero T
+	if it.err != nil {
+		return zero, false
+	}
+
+	v, ok, err := it.read()
+	if err != nil {
+		it.err = &RecordError{Line: it.line(), Err: err}
+		return zero, false
+	}
+	return v, ok
+}
+
+// Line returns the line of the record returned last.
+func (it *RecordIterator[T]) Line() int {
+	return it.line()
+}
+
+// Err returns the error which stopped the iteration.
+func (it *RecordIterator[T]) Err() error {
+	return it.err
+}
+
+// Close closes the file opened by OpenRecordIterator or OpenCSVIterator.
+// It does nothing for an iterator over a reader.
+func (it *RecordIterator[T]) Close() error {
+	if it.closer == nil {
+		return nil
+	}
+	return it.closer.Close()
+}
+
+// Lines decodes a record as a string.
+func Lines(record []byte) (string, error) {
+	return string(record), nil
+}
+
+// JSONLines decodes a record of JSON Lines into a T.
+func JSONLines[T any](record []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(record, &v)
+	return v, err
+}
+
+// splitOn is a bufio.SplitFunc splitting at delim.
+func splitOn(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
@@ -0,0 +1,88 @@
+package iter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordIterator(t *testing.T) {
+	it := NewRecordIterator(strings.NewReader("a\r\nb\n\nc"), Lines)
+	assert.Equal(t, []string{"a", "b", "", "c"}, Collect[string](it))
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 4, it.Line())
+
+	it = NewRecordIterator(strings.NewReader("a\n \nb\n"), Lines, SkipBlankLines())
+	assert.Equal(t, []string{"a", "b"}, Collect[string](it))
+
+	it = NewRecordIterator(strings.NewReader("a||b\n||c||"), Lines, WithDelimiter("||"))
+	assert.Equal(t, []string{"a", "b\n", "c"}, Collect[string](it))
+	assert.NoError(t, it.Close(), "Expected Close to do nothing for a reader")
+
+	it = NewRecordIterator(strings.NewReader("a\nb"), Lines, WithDelimiter(""))
+	assert.Equal(t, []string{"a", "b"}, Collect[string](it), "Expected an empty delimiter to split at line ends")
+}
+
+func TestRecordIterator_LongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	it := NewRecordIterator(strings.NewReader("a\n"+long+"\nb\n"), Lines)
+	got := Collect[string](it)
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"a", long, "b"}, got)
+
+	it = NewRecordIterator(strings.NewReader("a\n"+long+"\nb\n"), Lines, WithMaxRecordSize(1024))
+	assert.Equal(t, []string{"a"}, Collect[string](it))
+
+	var rerr *RecordError
+	assert.True(t, errors.As(it.Err(), &rerr))
+	assert.Equal(t, 2, rerr.Line)
+	assert.ErrorIs(t, it.Err(), bufio.ErrTooLong)
+}
+
+type event struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestRecordIterator_JSONLines(t *testing.T) {
+	input := "{\"id\":1,\"name\":\"a\"}\n\n{\"id\":2,\"name\":\"b\"}\n{\"id\":\"x\"}\n{\"id\":4}\n"
+
+	it := NewRecordIterator(strings.NewReader(input), JSONLines[event], SkipBlankLines())
+	assert.Equal(t, []event{{1, "a"}, {2, "b"}}, Collect[event](it))
+	assert.EqualError(t, it.Err(), "line 4: json: cannot unmarshal string into Go struct field event.id of type int")
+
+	_, ok := it.Next()
+	assert.False(t, ok, "Expected the iteration to stop at the error")
+}
+
+func TestOpenRecordIterator(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.jsonl")
+	assert.NoError(t, os.WriteFile(plain, []byte("{\"id\":1}\n{\"id\":2}\n"), 0o600))
+
+	compressed := filepath.Join(dir, "compressed.jsonl.gz")
+	f, err := os.Create(compressed)
+	assert.NoError(t, err)
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write([]byte("{\"id\":1}\n{\"id\":2}\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	for _, path := range []string{plain, compressed} {
+		it, err := OpenRecordIterator(path, JSONLines[event])
+		assert.NoError(t, err)
+		assert.Equal(t, []event{{ID: 1}, {ID: 2}}, Collect[event](it), path)
+		assert.NoError(t, it.Err())
+		assert.NoError(t, it.Close())
+	}
+
+	_, err = OpenRecordIterator(filepath.Join(dir, "missing"), Lines)
+	assert.Error(t, err)
+}
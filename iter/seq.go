@@ -0,0 +1,129 @@
+package iter
+
+import (
+	"io/fs"
+	goiter "iter"
+	"os"
+	"path/filepath"
+)
+
+// ToSeq returns a push iterator over the remaining values of it.
+func ToSeq[T any](it Iterator[T]) goiter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v, ok := it.Next(); ok; v, ok = it.Next() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// ToSeq2 returns a push iterator over the remaining pairs of it.
+func ToSeq2[K, V any](it Iterator[Pair[K, V]]) goiter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for p, ok := it.Next(); ok; p, ok = it.Next() {
+			if !yield(p.First, p.Second) {
+				return
+			}
+		}
+	}
+}
+
+// FromSeq converts a push iterator into an Iterator. The stop function
+// must be called when the Iterator is no longer used, unless it has been
+// exhausted.
+func FromSeq[T any](seq goiter.Seq[T]) (Iterator[T], func()) {
+	next, stop := goiter.Pull(seq)
+	return IteratorFunc[T](next), stop
+}
+
+// FromSeq2 converts a push iterator of pairs into an Iterator. The stop
+// function must be called when the Iterator is no longer used, unless it
+// has been exhausted.
+func FromSeq2[K, V any](seq goiter.Seq2[K, V]) (Iterator[Pair[K, V]], func()) {
+	next, stop := goiter.Pull2(seq)
+	return IteratorFunc[Pair[K, V]](func() (Pair[K, V], bool) {
+		k, v, ok := next()
+		return Pair[K, V]{First: k, Second: v}, ok
+	}), stop
+}
+
+// Seq returns an endless push iterator over the loop. It is empty if the
+// LoopIteratorr has no data.
+func (iter *LoopIteratorr[T]) Seq() goiter.Seq[T] {
+	return ToSeq(iter.Iter())
+}
+
+// All is like Seq but also yields the index of every value in the data.
+func (iter *LoopIteratorr[T]) All() goiter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		for len(iter.data) > 0 {
+			i := iter.index
+			if !yield(i, iter.Next()) {
+				return
+			}
+		}
+	}
+}
+
+// Seq returns a push iterator over the rest of the range.
+func (it *IntRangeIterator) Seq() goiter.Seq[int] {
+	return ToSeq[int](it)
+}
+
+// All is like Seq but also yields the index of every value in the range,
+// counting from zero at Start.
+func (it *IntRangeIterator) All() goiter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for v, ok := it.Next(); ok; v, ok = it.Next() {
+			if !yield(v-it.Start, v) {
+				return
+			}
+		}
+	}
+}
+
+// Seq returns an endless push iterator over the range.
+func (it *LoopingIntRangeIterator) Seq() goiter.Seq[int] {
+	return ToSeq(it.Iter())
+}
+
+// All is like Seq but also yields the index of every value in the range,
+// counting from zero at Start.
+func (it *LoopingIntRangeIterator) All() goiter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		for {
+			v := it.Next()
+			if !yield(v-it.Start, v) {
+				return
+			}
+		}
+	}
+}
+
+// Seq returns an endless push iterator over the subdirectories. It stops
+// at the first error returned by Next, which is then reported by Err.
+func (iter *DirIterator) Seq() goiter.Seq[string] {
+	return ToSeq(iter.Iter())
+}
+
+// All is like Seq but also yields the directory entry of every
+// subdirectory.
+func (iter *DirIterator) All() goiter.Seq2[string, fs.DirEntry] {
+	return func(yield func(string, fs.DirEntry) bool) {
+		for dir := range iter.Seq() {
+			info, err := os.Lstat(filepath.Join(iter.root, dir))
+			if os.IsNotExist(err) {
+				// Removed since it was listed.
+				continue
+			}
+			if err != nil {
+				iter.err = err
+				return
+			}
+			if !yield(dir, fs.FileInfoToDirEntry(info)) {
+				return
+			}
+		}
+	}
+}
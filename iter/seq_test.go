@@ -0,0 +1,103 @@
+package iter
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToSeq(t *testing.T) {
+	var got []int
+	for v := range ToSeq(FromSlice([]int{1, 2, 3, 4})) {
+		if v == 3 {
+			break
+		}
+		got = append(got, v)
+	}
+	assert.Equal(t, []int{1, 2}, got)
+
+	keys := []int{}
+	for k, v := range ToSeq2(Enumerate(FromSlice([]string{"a", "b"}))) {
+		keys = append(keys, k)
+		assert.Equal(t, []string{"a", "b"}[k], v)
+	}
+	assert.Equal(t, []int{0, 1}, keys)
+}
+
+func TestFromSeq(t *testing.T) {
+	it, stop := FromSeq(slices.Values([]int{1, 2, 3}))
+	defer stop()
+	assert.Equal(t, []int{1, 2}, Collect(Take(it, 2)))
+
+	it2, stop2 := FromSeq2(slices.All([]string{"a", "b"}))
+	defer stop2()
+	assert.Equal(t, []Pair[int, string]{{0, "a"}, {1, "b"}}, Collect(it2))
+}
+
+func TestIntRangeIterator_Seq(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 3}, slices.Collect(NewIntRangeIterator(1, 3).Seq()))
+
+	var idx, vals []int
+	for i, v := range NewIntRangeIterator(5, 7).All() {
+		idx = append(idx, i)
+		vals = append(vals, v)
+	}
+	assert.Equal(t, []int{0, 1, 2}, idx)
+	assert.Equal(t, []int{5, 6, 7}, vals)
+}
+
+func TestLoopingIterators_Seq(t *testing.T) {
+	var got []int
+	for v := range NewLoopingIntRangeIterator(1, 2).Seq() {
+		if len(got) == 5 {
+			break
+		}
+		got = append(got, v)
+	}
+	assert.Equal(t, []int{1, 2, 1, 2, 1}, got)
+
+	var idx []int
+	var vals []string
+	for i, v := range NewLoopIterator([]string{"a", "b"}).All() {
+		if len(idx) == 3 {
+			break
+		}
+		idx = append(idx, i)
+		vals = append(vals, v)
+	}
+	assert.Equal(t, []int{0, 1, 0}, idx)
+	assert.Equal(t, []string{"a", "b", "a"}, vals)
+
+	for range NewLoopIterator[int](nil).Seq() {
+		t.Fatal("Expected an empty loop to yield nothing")
+	}
+}
+
+func TestDirIterator_All(t *testing.T) {
+	rootDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "a"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(rootDir, "b"), 0o755))
+
+	iter, err := NewDirIterator(rootDir)
+	assert.NoError(t, err)
+
+	var dirs []string
+	for dir, entry := range iter.All() {
+		if len(dirs) == 2 {
+			break
+		}
+		assert.True(t, entry.IsDir())
+		assert.Equal(t, dir, entry.Name())
+		dirs = append(dirs, dir)
+	}
+	assert.Equal(t, []string{"a", "b"}, dirs)
+
+	assert.NoError(t, os.RemoveAll(rootDir))
+	for range iter.Seq() {
+		t.Fatal("Expected no directories after the root was removed")
+	}
+	assert.Error(t, iter.Err())
+}
@@ -0,0 +1,89 @@
+package iter
+
+import "math/rand"
+
+// ShuffleOption configures a ShuffleIterator.
+type ShuffleOption func(*shuffleConfig)
+
+type shuffleConfig struct {
+	avoidBoundaryRepeat bool
+}
+
+// AvoidBoundaryRepeat keeps a pass from starting with the element which
+// ended the previous one, so no element is returned twice in a row.
+func AvoidBoundaryRepeat() ShuffleOption {
+	return func(c *shuffleConfig) {
+		c.avoidBoundaryRepeat = true
+	}
+}
+
+// ShuffleIterator is an endless iterator which returns the elements of an
+// array in random order. Every pass returns each element exactly once and
+// the next pass uses a new order. The sequence of orders only depends on
+// the seed. It is not safe for concurrent use. ShuffleIterator implements
+// Iterator[T].
+type ShuffleIterator[T any] struct {
+	data  []T
+	seed  int64
+	cfg   shuffleConfig
+	rng   *rand.Rand
+	order []int
+	pos   int
+}
+
+// NewShuffleIterator creates a new ShuffleIterator for the given array.
+func NewShuffleIterator[T any](data []T, seed int64, opts ...ShuffleOption) *ShuffleIterator[T] {
+	it := &ShuffleIterator[T]{data: data, seed: seed}
+	for _, opt := range opts {
+		opt(&it.cfg)
+	}
+	it.Reset()
+	return it
+}
+
+// Next returns the next element. It returns false if the array is empty.
+func (it *ShuffleIterator[T]) Next() (T, bool) {
+	if len(it.data) == 0 {
+		var zero T
+		return zero, false
+	}
+
+	if it.pos == len(it.order) {
+		it.shuffle()
+	}
+	v := it.data[it.order[it.pos]]
+	it.pos++
+	return v, true
+}
+
+// Reset restarts the sequence of passes from the seed.
+func (it *ShuffleIterator[T]) Reset() {
+	//#nosec G404 -- A reproducible order, not a secret.
+	it.rng = rand.New(rand.NewSource(it.seed))
+	it.order = it.order[:0]
+	it.pos = 0
+}
+
+// shuffle starts a new pass.
+func (it *ShuffleIterator[T]) shuffle() {
+	last := -1
+	if len(it.order) > 0 {
+		last = it.order[len(it.order)-1]
+	}
+
+	if len(it.order) != len(it.data) {
+		it.order = make([]int, len(it.data))
+		for i := range it.order {
+			it.order[i] = i
+		}
+	}
+	it.rng.Shuffle(len(it.order), func(i, j int) {
+		it.order[i], it.order[j] = it.order[j], it.order[i]
+	})
+
+	if it.cfg.avoidBoundaryRepeat && len(it.order) > 1 && it.order[0] == last {
+		j := 1 + it.rng.Intn(len(it.order)-1)
+		it.order[0], it.order[j] = it.order[j], it.order[0]
+	}
+	it.pos = 0
+}
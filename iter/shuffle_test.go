@@ -0,0 +1,46 @@
+package iter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShuffleIterator(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	it := NewShuffleIterator(data, 42)
+
+	var passes [][]int
+	for i := 0; i < 5; i++ {
+		pass := Collect(Take[int](it, len(data)))
+		assert.ElementsMatch(t, data, pass, "Expected every element once per pass")
+		passes = append(passes, pass)
+	}
+	assert.NotEqual(t, passes[0], passes[1], "Expected a new order for every pass")
+
+	same := NewShuffleIterator(data, 42)
+	assert.Equal(t, passes[0], Collect(Take[int](same, len(data))), "Expected the seed to fix the order")
+
+	it.Reset()
+	assert.Equal(t, passes[0], Collect(Take[int](it, len(data))))
+
+	other := NewShuffleIterator(data, 7)
+	assert.NotEqual(t, passes[0], Collect(Take[int](other, len(data))))
+}
+
+func TestShuffleIterator_AvoidBoundaryRepeat(t *testing.T) {
+	for _, data := range [][]string{{"a", "b"}, {"a", "b", "c"}} {
+		it := NewShuffleIterator(data, 1, AvoidBoundaryRepeat())
+
+		got := Collect(Take[string](it, 1000*len(data)))
+		for i := 1; i < len(got); i++ {
+			assert.NotEqual(t, got[i-1], got[i], "Expected no repeat at position %d", i)
+		}
+	}
+
+	single := NewShuffleIterator([]string{"a"}, 1, AvoidBoundaryRepeat())
+	assert.Equal(t, []string{"a", "a"}, Collect(Take[string](single, 2)))
+
+	_, ok := NewShuffleIterator[int](nil, 1).Next()
+	assert.False(t, ok, "Expected an empty iterator to be exhausted")
+}
@@ -0,0 +1,145 @@
+package iter
+
+import "sync"
+
+// Weighted is a value with its weight in a WeightedIterator.
+type Weighted[T any] struct {
+	Value  T
+	Weight int
+}
+
+type weightedItem[T any] struct {
+	Weighted[T]
+	current int
+}
+
+// WeightedIterator is an endless iterator which returns every value in
+// proportion to its weight, using the smooth weighted round-robin of nginx:
+// for weights 5, 1 and 1 the sequence is a a b a c a a, instead of five a
+// in a row. The sequence only depends on the weights and the order the
+// values were added in. Values with a weight of zero or less are skipped.
+// WeightedIterator is safe for concurrent use and implements Iterator[T].
+type WeightedIterator[T comparable] struct {
+	mu    sync.Mutex
+	items []weightedItem[T]
+	total int
+}
+
+// NewWeightedIterator creates a new WeightedIterator with the given values.
+func NewWeightedIterator[T comparable](items ...Weighted[T]) *WeightedIterator[T] {
+	w := &WeightedIterator[T]{}
+	for _, item := range items {
+		w.Add(item.Value, item.Weight)
+	}
+	return w
+}
+
+// Next returns the next value. It returns false if no value has a positive
+// weight.
+func (w *WeightedIterator[T]) Next() (T, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var best *weightedItem[T]
+	for i := range w.items {
+		item := &w.items[i]
+		if item.Weight <= 0 {
+			continue
+		}
+		item.current += item.Weight
+		if best == nil || item.current > best.current {
+			best = item
+		}
+	}
+
+	if best == nil {
+		var zero T
+		return zero, false
+	}
+	best.current -= w.total
+	return best.Value, true
+}
+
+// Add adds value with the given weight, or changes the weight if value was
+// added before.
+func (w *WeightedIterator[T]) Add(value T, weight int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if i := w.find(value); i >= 0 {
+		w.setWeight(i, weight)
+		return
+	}
+	w.items = append(w.items, weightedItem[T]{Weighted: Weighted[T]{Value: value, Weight: weight}})
+	w.total += max(weight, 0)
+}
+
+// SetWeight changes the weight of value. The change takes effect on the
+// next call to Next. It returns false if value was not added.
+func (w *WeightedIterator[T]) SetWeight(value T, weight int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.find(value)
+	if i < 0 {
+		return false
+	}
+	w.setWeight(i, weight)
+	return true
+}
+
+// Remove removes value. It returns false if value was not added.
+func (w *WeightedIterator[T]) Remove(value T) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	i := w.find(value)
+	if i < 0 {
+		return false
+	}
+	w.setWeight(i, 0)
+	w.items = append(w.items[:i], w.items[i+1:]...)
+	return true
+}
+
+// Weights returns the values with their current weights, in the order they
+// were added.
+func (w *WeightedIterator[T]) Weights() []Weighted[T] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]Weighted[T], len(w.items))
+	for i, item := range w.items {
+		out[i] = item.Weighted
+	}
+	return out
+}
+
+// Reset restarts the sequence from the beginning.
+func (w *WeightedIterator[T]) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.items {
+		w.items[i].current = 0
+	}
+}
+
+func (w *WeightedIterator[T]) find(value T) int {
+	for i, item := range w.items {
+		if item.Value == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *WeightedIterator[T]) setWeight(i int, weight int) {
+	item := &w.items[i]
+	w.total += max(weight, 0) - max(item.Weight, 0)
+	item.Weight = weight
+	if weight <= 0 {
+		// A skipped value must not keep a head start.
+		item.current = 0
+	}
+}
@@ -0,0 +1,52 @@
+package iter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedIterator(t *testing.T) {
+	w := NewWeightedIterator(Weighted[string]{"a", 5}, Weighted[string]{"b", 1}, Weighted[string]{"c", 1})
+
+	want := []string{"a", "a", "b", "a", "c", "a", "a"}
+	assert.Equal(t, append(want, want...), Collect(Take[string](w, 14)), "Expected the smooth nginx sequence")
+
+	w.Reset()
+	assert.Equal(t, want, Collect(Take[string](w, 7)))
+}
+
+func TestWeightedIterator_Shares(t *testing.T) {
+	w := NewWeightedIterator(Weighted[int]{1, 3}, Weighted[int]{2, 2}, Weighted[int]{3, 0})
+
+	counts := map[int]int{}
+	for _, v := range Collect(Take[int](w, 500)) {
+		counts[v]++
+	}
+	assert.Equal(t, map[int]int{1: 300, 2: 200}, counts)
+}
+
+func TestWeightedIterator_Update(t *testing.T) {
+	w := NewWeightedIterator[string]()
+
+	_, ok := w.Next()
+	assert.False(t, ok, "Expected an empty iterator to be exhausted")
+
+	w.Add("a", 1)
+	w.Add("b", 1)
+	assert.Equal(t, []string{"a", "b", "a", "b"}, Collect(Take[string](w, 4)))
+
+	assert.True(t, w.SetWeight("b", 3))
+	assert.False(t, w.SetWeight("x", 3))
+	assert.Equal(t, []string{"b", "a", "b", "b"}, Collect(Take[string](w, 4)))
+
+	w.Add("a", 0)
+	assert.Equal(t, []string{"b", "b"}, Collect(Take[string](w, 2)))
+
+	assert.True(t, w.Remove("b"))
+	assert.False(t, w.Remove("b"))
+	_, ok = w.Next()
+	assert.False(t, ok, "Expected no value with a positive weight")
+
+	assert.Equal(t, []Weighted[string]{{"a", 0}}, w.Weights())
+}